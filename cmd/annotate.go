@@ -0,0 +1,142 @@
+/*
+Copyright © 2021 MasseElch info@masseelch.de
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cmd
+
+import (
+	"fmt"
+	"github.com/masseelch/wapiti/wapiti"
+	"github.com/spf13/cobra"
+)
+
+// annotateFlags holds the values of the entsql annotation flags.
+var annotateFlags struct {
+	table, charset, collation, options string
+	onDelete                           string
+	size                               int64
+	def                                string
+}
+
+// annotateCmd groups the commands setting entsql annotations.
+var annotateCmd = &cobra.Command{
+	Use:   "annotate",
+	Short: "Set the SQL annotations of a schema, an edge or a field",
+}
+
+// annotateSchemaCmd sets the table options of a schema.
+var annotateSchemaCmd = &cobra.Command{
+	Use:     "schema <schema>",
+	Short:   "Set the table name, charset, collation and options of a schema",
+	Long:    "Set the table name, charset, collation and options of a schema. Options not given are kept, pass an empty value to remove one.",
+	Example: "wapiti annotate schema User --table users --charset utf8mb4 --collation utf8mb4_bin",
+	Args:    cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		w, err := wapiti.New(cfg)
+		fatalOnErr(err)
+		s, err := lookupNode(w, args[0])
+		fatalOnErr(err)
+		a, err := wapiti.SQLAnnotation(s.Annotations)
+		fatalOnErr(err)
+		flags := cmd.Flags()
+		if flags.Changed("table") {
+			a.Table = annotateFlags.table
+		}
+		if flags.Changed("charset") {
+			a.Charset = annotateFlags.charset
+		}
+		if flags.Changed("collation") {
+			a.Collation = annotateFlags.collation
+		}
+		if flags.Changed("options") {
+			a.Options = annotateFlags.options
+		}
+		fatalOnErr(w.AnnotateSchema(s, a))
+	},
+}
+
+// annotateEdgeCmd sets the referential action of an edge.
+var annotateEdgeCmd = &cobra.Command{
+	Use:     "edge <schema> <edge>",
+	Short:   "Set the referential action on deletion of an edge",
+	Long:    "Set the referential action on deletion (NO_ACTION, RESTRICT, CASCADE, SET_NULL, SET_DEFAULT) of an edge. Pass an empty value to remove it.",
+	Example: "wapiti annotate edge User pets --on-delete cascade",
+	Args:    cobra.ExactArgs(2),
+	Run: func(cmd *cobra.Command, args []string) {
+		w, err := wapiti.New(cfg)
+		fatalOnErr(err)
+		s, err := lookupNode(w, args[0])
+		fatalOnErr(err)
+		var as map[string]interface{}
+		for _, e := range s.Edges {
+			if e.Name == args[1] {
+				as = e.Annotations
+			}
+		}
+		a, err := wapiti.SQLAnnotation(as)
+		fatalOnErr(err)
+		if cmd.Flags().Changed("on-delete") {
+			a.OnDelete, err = wapiti.ParseReferenceOption(annotateFlags.onDelete)
+			fatalOnErr(err)
+		}
+		fatalOnErr(w.AnnotateEdge(s, args[1], a))
+	},
+}
+
+// annotateFieldCmd sets the column size and default of a field.
+var annotateFieldCmd = &cobra.Command{
+	Use:     "field <schema> <field>",
+	Short:   "Set the column size and default of a field",
+	Long:    "Set the column size and default of a field. Options not given are kept, pass 0 or an empty value to remove one.",
+	Example: "wapiti annotate field User bio --size 1024",
+	Args:    cobra.ExactArgs(2),
+	Run: func(cmd *cobra.Command, args []string) {
+		w, err := wapiti.New(cfg)
+		fatalOnErr(err)
+		s, err := lookupNode(w, args[0])
+		fatalOnErr(err)
+		var as map[string]interface{}
+		for _, f := range s.Fields {
+			if f.Name == args[1] {
+				as = f.Annotations
+			}
+		}
+		a, err := wapiti.SQLAnnotation(as)
+		fatalOnErr(err)
+		flags := cmd.Flags()
+		if flags.Changed("size") {
+			if annotateFlags.size < 0 {
+				fatalOnErr(fmt.Errorf("invalid size %d", annotateFlags.size))
+			}
+			a.Size = annotateFlags.size
+		}
+		if flags.Changed("default") {
+			a.Default = annotateFlags.def
+		}
+		fatalOnErr(w.AnnotateField(s, args[1], a))
+	},
+}
+
+func init() {
+	annotateSchemaCmd.Flags().StringVar(&annotateFlags.table, "table", "", "name of the table")
+	annotateSchemaCmd.Flags().StringVar(&annotateFlags.charset, "charset", "", "charset of the table, e.g. utf8mb4")
+	annotateSchemaCmd.Flags().StringVar(&annotateFlags.collation, "collation", "", "collation of the table, e.g. utf8mb4_bin")
+	annotateSchemaCmd.Flags().StringVar(&annotateFlags.options, "options", "", "additional table options, e.g. 'ENGINE = INNODB'")
+	annotateEdgeCmd.Flags().StringVar(&annotateFlags.onDelete, "on-delete", "", "referential action on deletion, e.g. cascade")
+	annotateFieldCmd.Flags().Int64Var(&annotateFlags.size, "size", 0, "size of the column")
+	annotateFieldCmd.Flags().StringVar(&annotateFlags.def, "default", "", "default of the column as SQL expression")
+	annotateCmd.AddCommand(annotateSchemaCmd, annotateEdgeCmd, annotateFieldCmd)
+	rootCmd.AddCommand(annotateCmd)
+}
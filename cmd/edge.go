@@ -0,0 +1,98 @@
+/*
+Copyright © 2021 MasseElch info@masseelch.de
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cmd
+
+import (
+	"errors"
+	"github.com/masseelch/wapiti/wapiti"
+	"github.com/spf13/cobra"
+)
+
+var edgeFlags struct {
+	o2o, o2m, m2o, m2m bool
+	ref, backRef       string
+	required           bool
+}
+
+// edgeCmd groups the commands working on edges.
+var edgeCmd = &cobra.Command{
+	Use:   "edge",
+	Short: "Manage the edges of a schema",
+}
+
+// edgeAddCmd adds an edge to a schema.
+var edgeAddCmd = &cobra.Command{
+	Use:   "add <schema> <name>:<schema>",
+	Short: "Add an edge to a schema",
+	Example: "wapiti edge add User pets:Pet --o2m --back-ref owner\n" +
+		"wapiti edge add Pet owner:User --m2o --ref pets",
+	Args: cobra.ExactArgs(2),
+	Run: func(cmd *cobra.Command, args []string) {
+		w, err := wapiti.New(cfg)
+		fatalOnErr(err)
+		s, err := lookupNode(w, args[0])
+		fatalOnErr(err)
+		name, typ := splitPair(args[1], s.Name)
+		rel, err := edgeRelation()
+		fatalOnErr(err)
+		unique, inverseUnique, err := wapiti.Cardinality(rel)
+		fatalOnErr(err)
+		e := &wapiti.Edge{
+			Schema:   s,
+			Name:     name,
+			Type:     typ,
+			Inverse:  edgeFlags.ref != "",
+			Ref:      edgeFlags.ref,
+			Unique:   unique,
+			Required: edgeFlags.required,
+		}
+		if e.Inverse && edgeFlags.backRef != "" {
+			fatalOnErr(errors.New("--ref and --back-ref cannot be used together"))
+		}
+		_, err = w.AddEdgeWithBackRef(e, edgeFlags.backRef, inverseUnique)
+		fatalOnErr(err)
+	},
+}
+
+func init() {
+	edgeAddCmd.Flags().BoolVar(&edgeFlags.o2o, "o2o", false, "one-to-one relation")
+	edgeAddCmd.Flags().BoolVar(&edgeFlags.o2m, "o2m", false, "one-to-many relation (default)")
+	edgeAddCmd.Flags().BoolVar(&edgeFlags.m2o, "m2o", false, "many-to-one relation")
+	edgeAddCmd.Flags().BoolVar(&edgeFlags.m2m, "m2m", false, "many-to-many relation")
+	edgeAddCmd.Flags().StringVar(&edgeFlags.ref, "ref", "", "create a back-reference (edge.From) of the given edge")
+	edgeAddCmd.Flags().StringVar(&edgeFlags.backRef, "back-ref", "", "also add a back-reference with the given name to the other schema")
+	edgeAddCmd.Flags().BoolVar(&edgeFlags.required, "required", false, "edge is required on creation")
+	edgeCmd.AddCommand(edgeAddCmd)
+	rootCmd.AddCommand(edgeCmd)
+}
+
+// edgeRelation returns the relation selected by the flags. Defaults to O2M.
+func edgeRelation() (string, error) {
+	var rel []string
+	for r, set := range map[string]bool{"O2O": edgeFlags.o2o, "O2M": edgeFlags.o2m, "M2O": edgeFlags.m2o, "M2M": edgeFlags.m2m} {
+		if set {
+			rel = append(rel, r)
+		}
+	}
+	switch len(rel) {
+	case 0:
+		return "O2M", nil
+	case 1:
+		return rel[0], nil
+	}
+	return "", errors.New("only one of --o2o, --o2m, --m2o and --m2m can be given")
+}
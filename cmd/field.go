@@ -0,0 +1,216 @@
+/*
+Copyright © 2021 MasseElch info@masseelch.de
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cmd
+
+import (
+	"entgo.io/ent/entc/load"
+	"fmt"
+	"github.com/masseelch/wapiti/wapiti"
+	"github.com/spf13/cobra"
+	"strings"
+)
+
+var fieldFlags struct {
+	name, typ, def                string
+	updateDefault                 bool
+	values                        []string
+	optional, nillable, immutable bool
+	unique, sensitive             bool
+	storageKey, structTag         string
+	comment, goType               string
+	schemaType                    map[string]string
+}
+
+// fieldCmd groups the commands working on fields.
+var fieldCmd = &cobra.Command{
+	Use:   "field",
+	Short: "Manage the fields of a schema",
+}
+
+// fieldAddCmd adds a field to a schema.
+var fieldAddCmd = &cobra.Command{
+	Use:     "add <schema> <name>[:<type>]",
+	Short:   "Add a field to a schema",
+	Example: "wapiti field add Pet name:string --optional --nillable",
+	Args:    cobra.ExactArgs(2),
+	Run: func(cmd *cobra.Command, args []string) {
+		w, err := wapiti.New(cfg)
+		fatalOnErr(err)
+		s, err := lookupNode(w, args[0])
+		fatalOnErr(err)
+		name, typ := splitPair(args[1], "string")
+		f := &wapiti.Field{
+			Schema:    s,
+			Name:      name,
+			Type:      typ,
+			Optional:  fieldFlags.optional,
+			Nillable:  fieldFlags.nillable,
+			Immutable: fieldFlags.immutable,
+			Unique:    fieldFlags.unique,
+			Sensitive: fieldFlags.sensitive,
+		}
+		fatalOnErr(setFieldFlags(cmd, w, f))
+		_, err = w.AddField(f)
+		fatalOnErr(err)
+	},
+}
+
+// fieldEditCmd changes a field of a schema.
+var fieldEditCmd = &cobra.Command{
+	Use:     "edit <schema> <name>",
+	Short:   "Change the name, type or options of a field",
+	Example: "wapiti field edit Pet name --type text --optional=false",
+	Args:    cobra.ExactArgs(2),
+	Run: func(cmd *cobra.Command, args []string) {
+		w, err := wapiti.New(cfg)
+		fatalOnErr(err)
+		s, err := lookupNode(w, args[0])
+		fatalOnErr(err)
+		f, err := w.FieldOf(s, args[1])
+		fatalOnErr(err)
+		// Only change what was explicitly requested.
+		flags := cmd.Flags()
+		if flags.Changed("name") {
+			f.Name = fieldFlags.name
+		}
+		if flags.Changed("type") {
+			f.Type = fieldFlags.typ
+		}
+		if flags.Changed("optional") {
+			f.Optional = fieldFlags.optional
+		}
+		if flags.Changed("nillable") {
+			f.Nillable = fieldFlags.nillable
+		}
+		if flags.Changed("immutable") {
+			f.Immutable = fieldFlags.immutable
+		}
+		if flags.Changed("unique") {
+			f.Unique = fieldFlags.unique
+		}
+		if flags.Changed("sensitive") {
+			f.Sensitive = fieldFlags.sensitive
+		}
+		fatalOnErr(setFieldFlags(cmd, w, f))
+		_, err = w.UpdateField(s, args[1], f)
+		fatalOnErr(err)
+	},
+}
+
+// fieldRemoveCmd removes a field from a schema.
+var fieldRemoveCmd = &cobra.Command{
+	Use:     "remove <schema> <name>",
+	Short:   "Remove a field from a schema",
+	Example: "wapiti field remove Pet name",
+	Args:    cobra.ExactArgs(2),
+	Run: func(cmd *cobra.Command, args []string) {
+		w, err := wapiti.New(cfg)
+		fatalOnErr(err)
+		s, err := lookupNode(w, args[0])
+		fatalOnErr(err)
+		fatalOnErr(w.RemoveField(s, args[1]))
+	},
+}
+
+func init() {
+	for _, c := range []*cobra.Command{fieldAddCmd, fieldEditCmd} {
+		c.Flags().BoolVar(&fieldFlags.optional, "optional", false, "field is not required on creation")
+		c.Flags().BoolVar(&fieldFlags.nillable, "nillable", false, "field can be nil")
+		c.Flags().BoolVar(&fieldFlags.immutable, "immutable", false, "field cannot be updated after creation")
+		c.Flags().StringVar(&fieldFlags.def, "default", "", "default value of the field (time.Now for time, uuid.New for uuid fields)")
+		c.Flags().BoolVar(&fieldFlags.updateDefault, "update-default", false, "set a time field to the current time on updates")
+		c.Flags().StringSliceVar(&fieldFlags.values, "values", nil, "values of an enum field, use name=value for named values")
+		c.Flags().BoolVar(&fieldFlags.unique, "unique", false, "values of the field must be unique")
+		c.Flags().BoolVar(&fieldFlags.sensitive, "sensitive", false, "field is not printed or serialized")
+		c.Flags().StringVar(&fieldFlags.storageKey, "storage-key", "", "name of the column")
+		c.Flags().StringVar(&fieldFlags.structTag, "struct-tag", "", "struct tag of the field in the generated entity")
+		c.Flags().StringVar(&fieldFlags.comment, "comment", "", "comment of the field")
+		c.Flags().StringVar(&fieldFlags.goType, "go-type", "", "custom Go type of the field, e.g. *models.Point (required for json and other fields)")
+		c.Flags().StringToStringVar(&fieldFlags.schemaType, "schema-type", nil, "database type of the column per dialect, e.g. mysql=varchar(64)")
+	}
+	fieldEditCmd.Flags().StringVar(&fieldFlags.name, "name", "", "new name of the field")
+	fieldEditCmd.Flags().StringVar(&fieldFlags.typ, "type", "", "new type of the field")
+	fieldCmd.AddCommand(fieldAddCmd, fieldEditCmd, fieldRemoveCmd)
+	rootCmd.AddCommand(fieldCmd)
+}
+
+// setFieldFlags sets the options of the field given by flags that need parsing.
+func setFieldFlags(cmd *cobra.Command, w *wapiti.Wapiti, f *wapiti.Field) error {
+	if cmd.Flags().Changed("values") {
+		es, err := wapiti.ParseEnums(fieldFlags.values)
+		if err != nil {
+			return err
+		}
+		f.Enums = es
+	}
+	if cmd.Flags().Changed("default") {
+		f.Default = ""
+		if fieldFlags.def != "" {
+			d, err := wapiti.DefaultExpr(f.Type, fieldFlags.def)
+			if err != nil {
+				return err
+			}
+			f.Default = d
+		}
+	}
+	if cmd.Flags().Changed("update-default") {
+		f.UpdateDefault = ""
+		if fieldFlags.updateDefault {
+			f.UpdateDefault = "time.Now"
+		}
+	}
+	if cmd.Flags().Changed("storage-key") {
+		f.StorageKey = fieldFlags.storageKey
+	}
+	if cmd.Flags().Changed("struct-tag") {
+		f.StructTag = fieldFlags.structTag
+	}
+	if cmd.Flags().Changed("comment") {
+		f.Comment = fieldFlags.comment
+	}
+	if cmd.Flags().Changed("schema-type") {
+		f.SchemaType = fieldFlags.schemaType
+	}
+	if cmd.Flags().Changed("go-type") {
+		f.GoType, f.Imports = "", nil
+		if fieldFlags.goType != "" {
+			t, pkgs, err := w.ResolveGoType(fieldFlags.goType)
+			if err != nil {
+				return err
+			}
+			f.GoType, f.Imports = t, pkgs
+		}
+	}
+	return nil
+}
+
+// lookupNode returns the schema with the given name or an error if there is no such schema.
+func lookupNode(w *wapiti.Wapiti, name string) (*load.Schema, error) {
+	s := w.LookupNode(name)
+	if s == nil {
+		return nil, fmt.Errorf("unknown schema %s", name)
+	}
+	return s, nil
+}
+
+// splitPair splits an argument of the form 'name:value'. If there is no value def is returned as value.
+func splitPair(arg, def string) (string, string) {
+	if i := strings.Index(arg, ":"); i >= 0 {
+		return arg[:i], arg[i+1:]
+	}
+	return arg, def
+}
@@ -0,0 +1,49 @@
+/*
+Copyright © 2021 MasseElch info@masseelch.de
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cmd
+
+import (
+	"github.com/masseelch/wapiti/wapiti"
+	"github.com/spf13/cobra"
+)
+
+// hookCmd groups the commands working on hooks.
+var hookCmd = &cobra.Command{
+	Use:   "hook",
+	Short: "Manage the hooks of a schema",
+}
+
+// hookAddCmd adds a hook skeleton to a schema.
+var hookAddCmd = &cobra.Command{
+	Use:     "add <schema> <operation>...",
+	Short:   "Add a hook running on the given operations to a schema",
+	Long:    "Add a hook running on the given operations (Create, Update, UpdateOne, Delete, DeleteOne) to a schema.",
+	Example: "wapiti hook add Pet Create UpdateOne",
+	Args:    cobra.MinimumNArgs(2),
+	Run: func(cmd *cobra.Command, args []string) {
+		w, err := wapiti.New(cfg)
+		fatalOnErr(err)
+		s, err := lookupNode(w, args[0])
+		fatalOnErr(err)
+		fatalOnErr(w.AddHook(&wapiti.Hook{Schema: s, Ops: args[1:]}))
+	},
+}
+
+func init() {
+	hookCmd.AddCommand(hookAddCmd)
+	rootCmd.AddCommand(hookCmd)
+}
@@ -0,0 +1,112 @@
+/*
+Copyright © 2021 MasseElch info@masseelch.de
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cmd
+
+import (
+	"fmt"
+	"github.com/logrusorgru/aurora/v3"
+	"github.com/masseelch/wapiti/wapiti"
+	"github.com/spf13/cobra"
+	"io/ioutil"
+)
+
+// importFlags holds the values of the flags of the import commands.
+var importFlags struct {
+	dialect string
+	plan    bool
+	merge   bool
+}
+
+// importCmd groups the commands creating schemas from existing data models.
+var importCmd = &cobra.Command{
+	Use:   "import",
+	Short: "Create schemas from existing data models",
+}
+
+// importSQLCmd creates schemas from a DDL script.
+var importSQLCmd = &cobra.Command{
+	Use:   "sql <file>",
+	Short: "Create schemas from the CREATE TABLE statements of a SQL file",
+	Long: "Create schemas from the CREATE TABLE, CREATE INDEX and ALTER TABLE statements of a SQL file written for " +
+		"MySQL, PostgreSQL or SQLite. Foreign keys become edges, tables consisting of two foreign keys only become M2M " +
+		"edges. Existing schemas are only changed if --merge is given.",
+	Example: "wapiti import sql schema.sql --dialect postgres",
+	Args:    cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		b, err := ioutil.ReadFile(args[0])
+		fatalOnErr(err)
+		sf, notes, err := wapiti.ImportSQL(string(b), importFlags.dialect)
+		fatalOnErr(err)
+		importSpec(sf, notes)
+	},
+}
+
+// importSQLiteCmd creates schemas from a SQLite database file.
+var importSQLiteCmd = &cobra.Command{
+	Use:   "sqlite <file>",
+	Short: "Create schemas from the tables of a SQLite database file",
+	Long: "Create schemas from the tables, indexes and foreign keys of a SQLite database file. The file is read " +
+		"directly, no database connection is opened. Existing schemas are only changed if --merge is given.",
+	Example: "wapiti import sqlite app.db",
+	Args:    cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		sf, notes, err := wapiti.ImportSQLite(args[0])
+		fatalOnErr(err)
+		importSpec(sf, notes)
+	},
+}
+
+// importGoCmd creates schemas from the struct types of a Go package.
+var importGoCmd = &cobra.Command{
+	Use:   "go <dir>",
+	Short: "Create schemas from the struct types of a Go package, e.g. GORM models",
+	Long: "Create schemas from the exported struct types of the Go package in the given directory. Fields are mapped " +
+		"by their Go type and their gorm, db and json tags, relations between the structs become edges. Existing " +
+		"schemas are only changed if --merge is given.",
+	Example: "wapiti import go ./models",
+	Args:    cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		sf, notes, err := wapiti.ImportGo(args[0])
+		fatalOnErr(err)
+		importSpec(sf, notes)
+	},
+}
+
+func init() {
+	importSQLCmd.Flags().StringVar(&importFlags.dialect, "dialect", "", "dialect of the SQL file: mysql, postgres or sqlite3 (detected if not given)")
+	importCmd.PersistentFlags().BoolVar(&importFlags.plan, "plan", false, "only print the changes instead of applying them")
+	importCmd.PersistentFlags().BoolVar(&importFlags.merge, "merge", false, "add missing fields, edges and indexes to existing schemas")
+	importCmd.AddCommand(importSQLCmd, importSQLiteCmd, importGoCmd)
+	rootCmd.AddCommand(importCmd)
+}
+
+// importSpec prints the notes of an import and plans the SpecFile. Existing schemas are left alone unless merging is
+// requested. The changes are applied unless only the plan is requested.
+func importSpec(sf *wapiti.SpecFile, notes []string) {
+	for _, n := range notes {
+		fmt.Println(aurora.Yellow("note: " + n))
+	}
+	w, err := wapiti.New(cfg)
+	fatalOnErr(err)
+	fatalOnErr(w.CheckOverwrites(sf, importFlags.merge))
+	cs, err := w.Plan(sf)
+	fatalOnErr(err)
+	printPlan(cs)
+	if !importFlags.plan {
+		fatalOnErr(w.Apply(cs))
+	}
+}
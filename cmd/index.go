@@ -0,0 +1,68 @@
+/*
+Copyright © 2021 MasseElch info@masseelch.de
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cmd
+
+import (
+	"github.com/masseelch/wapiti/wapiti"
+	"github.com/spf13/cobra"
+	"strings"
+)
+
+var indexFlags struct {
+	edges      []string
+	unique     bool
+	storageKey string
+}
+
+// indexCmd groups the commands working on indexes.
+var indexCmd = &cobra.Command{
+	Use:   "index",
+	Short: "Manage the indexes of a schema",
+}
+
+// indexAddCmd adds an index to a schema.
+var indexAddCmd = &cobra.Command{
+	Use:     "add <schema> <field>[,<field>...]",
+	Short:   "Add an index to a schema",
+	Example: "wapiti index add Pet name,age --edges owner --unique",
+	Args:    cobra.RangeArgs(1, 2),
+	Run: func(cmd *cobra.Command, args []string) {
+		w, err := wapiti.New(cfg)
+		fatalOnErr(err)
+		s, err := lookupNode(w, args[0])
+		fatalOnErr(err)
+		i := &wapiti.Index{
+			Schema:     s,
+			Edges:      indexFlags.edges,
+			Unique:     indexFlags.unique,
+			StorageKey: indexFlags.storageKey,
+		}
+		if len(args) == 2 {
+			i.Fields = strings.Split(args[1], ",")
+		}
+		_, err = w.AddIndex(i)
+		fatalOnErr(err)
+	},
+}
+
+func init() {
+	indexAddCmd.Flags().StringSliceVar(&indexFlags.edges, "edges", nil, "edges of the index")
+	indexAddCmd.Flags().BoolVar(&indexFlags.unique, "unique", false, "index is unique")
+	indexAddCmd.Flags().StringVar(&indexFlags.storageKey, "storage-key", "", "storage key of the index")
+	indexCmd.AddCommand(indexAddCmd)
+	rootCmd.AddCommand(indexCmd)
+}
@@ -0,0 +1,48 @@
+/*
+Copyright © 2021 MasseElch info@masseelch.de
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cmd
+
+import (
+	"github.com/masseelch/wapiti/wapiti"
+	"github.com/spf13/cobra"
+	"os"
+)
+
+// inferCmd infers the fields of a schema from sample JSON documents.
+var inferCmd = &cobra.Command{
+	Use:   "infer <schema> <file>",
+	Short: "Infer the fields of a schema from sample JSON documents",
+	Long: "Infer the fields of a schema from a JSON file holding an object, an array of objects or one object per " +
+		"line (NDJSON). Every inferred field is proposed for confirmation before it is added. The schema is created " +
+		"if it does not exist yet.",
+	Example: "wapiti infer Pet sample.json",
+	Args:    cobra.ExactArgs(2),
+	Run: func(cmd *cobra.Command, args []string) {
+		f, err := os.Open(args[1])
+		fatalOnErr(err)
+		defer f.Close()
+		fs, id, err := wapiti.InferFields(f)
+		fatalOnErr(err)
+		w, err := wapiti.New(cfg)
+		fatalOnErr(err)
+		fatalOnErr(w.InferSchema(args[0], id, fs))
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(inferCmd)
+}
@@ -0,0 +1,81 @@
+/*
+Copyright © 2021 MasseElch info@masseelch.de
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cmd
+
+import (
+	"fmt"
+	"github.com/logrusorgru/aurora/v3"
+	"github.com/masseelch/wapiti/wapiti"
+	"github.com/spf13/cobra"
+)
+
+// mixinCmd groups the commands working on mixins.
+var mixinCmd = &cobra.Command{
+	Use:   "mixin",
+	Short: "Manage the mixins of a schema",
+}
+
+// mixinListCmd lists the mixins wapiti can add.
+var mixinListCmd = &cobra.Command{
+	Use:   "list",
+	Short: "List the mixins of ent and the ones declared in the module",
+	Args:  cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		w, err := wapiti.New(cfg)
+		fatalOnErr(err)
+		ts, err := w.MixinTypes()
+		fatalOnErr(err)
+		for _, t := range ts {
+			fmt.Printf("%s\t%s\n", aurora.Cyan(t.Name), t.Desc)
+		}
+	},
+}
+
+// mixinAddCmd adds a mixin to a schema.
+var mixinAddCmd = &cobra.Command{
+	Use:     "add <schema> <mixin>",
+	Short:   "Add a mixin to a schema",
+	Example: "wapiti mixin add Pet mixin.Time",
+	Args:    cobra.ExactArgs(2),
+	Run: func(cmd *cobra.Command, args []string) {
+		w, err := wapiti.New(cfg)
+		fatalOnErr(err)
+		s, err := lookupNode(w, args[0])
+		fatalOnErr(err)
+		ts, err := w.MixinTypes()
+		fatalOnErr(err)
+		var m *wapiti.Mixin
+		for _, t := range ts {
+			if t.Name == args[1] && t.Code != "" {
+				m = &wapiti.Mixin{Schema: s, Code: t.Code, Imports: t.Imports}
+			}
+		}
+		if m == nil {
+			fatalOnErr(fmt.Errorf("unknown mixin %s, see wapiti mixin list", args[1]))
+		}
+		fs, err := w.AddMixin(m)
+		fatalOnErr(err)
+		for _, f := range fs {
+			fmt.Printf("mixed in field %s\n", aurora.Cyan(f.Name))
+		}
+	},
+}
+
+func init() {
+	mixinCmd.AddCommand(mixinListCmd, mixinAddCmd)
+	rootCmd.AddCommand(mixinCmd)
+}
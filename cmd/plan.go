@@ -0,0 +1,79 @@
+/*
+Copyright © 2021 MasseElch info@masseelch.de
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cmd
+
+import (
+	"fmt"
+	"github.com/logrusorgru/aurora/v3"
+	"github.com/masseelch/wapiti/wapiti"
+	"github.com/spf13/cobra"
+)
+
+// planCmd prints the changes needed to reconcile the schemas with a spec file.
+var planCmd = &cobra.Command{
+	Use:     "plan <spec-file>",
+	Short:   "Show the changes needed to reconcile the schemas with a YAML/JSON spec file",
+	Example: "wapiti plan spec.yaml",
+	Args:    cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		_, cs := plan(args[0])
+		printPlan(cs)
+	},
+}
+
+// applyCmd reconciles the schemas with a spec file.
+var applyCmd = &cobra.Command{
+	Use:     "apply <spec-file>",
+	Short:   "Reconcile the schemas with a YAML/JSON spec file",
+	Example: "wapiti apply spec.yaml",
+	Args:    cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		w, cs := plan(args[0])
+		printPlan(cs)
+		fatalOnErr(w.Apply(cs))
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(planCmd, applyCmd)
+}
+
+// plan loads the spec file at the given path and plans the changes.
+func plan(path string) (*wapiti.Wapiti, []*wapiti.Change) {
+	sf, err := wapiti.LoadSpecFile(path)
+	fatalOnErr(err)
+	w, err := wapiti.New(cfg)
+	fatalOnErr(err)
+	cs, err := w.Plan(sf)
+	fatalOnErr(err)
+	return w, cs
+}
+
+// printPlan prints the given changes. Conflicts are highlighted.
+func printPlan(cs []*wapiti.Change) {
+	if len(cs) == 0 {
+		fmt.Println(aurora.Green("Schemas are up to date."))
+		return
+	}
+	for _, c := range cs {
+		if c.Conflict() {
+			fmt.Println(aurora.Yellow(c))
+		} else {
+			fmt.Println(aurora.Green(c))
+		}
+	}
+}
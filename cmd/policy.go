@@ -0,0 +1,65 @@
+/*
+Copyright © 2021 MasseElch info@masseelch.de
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cmd
+
+import (
+	"github.com/masseelch/wapiti/wapiti"
+	"github.com/spf13/cobra"
+	"strings"
+)
+
+var policyFlags struct {
+	mutation, query []string
+	owner           string
+}
+
+// policyCmd groups the commands working on privacy policies.
+var policyCmd = &cobra.Command{
+	Use:   "policy",
+	Short: "Manage the privacy policy of a schema",
+}
+
+// policyAddCmd adds a privacy policy to a schema.
+var policyAddCmd = &cobra.Command{
+	Use:   "add <schema>",
+	Short: "Add a privacy policy to a schema",
+	Long: "Add a privacy policy built from the rules " + strings.Join(wapiti.PolicyRules, ", ") + ` to a schema.
+
+A rule package holding the rules is created next to the schema package if it does not exist yet.`,
+	Example: "wapiti policy add Pet --mutation DenyIfNoViewer,OwnerOnly --query OwnerOnly --owner owner",
+	Args:    cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		w, err := wapiti.New(cfg)
+		fatalOnErr(err)
+		s, err := lookupNode(w, args[0])
+		fatalOnErr(err)
+		fatalOnErr(w.AddPolicy(&wapiti.Policy{
+			Schema:   s,
+			Mutation: policyFlags.mutation,
+			Query:    policyFlags.query,
+			Owner:    policyFlags.owner,
+		}))
+	},
+}
+
+func init() {
+	policyAddCmd.Flags().StringSliceVar(&policyFlags.mutation, "mutation", nil, "rules of mutations in the order they are evaluated")
+	policyAddCmd.Flags().StringSliceVar(&policyFlags.query, "query", nil, "rules of queries in the order they are evaluated")
+	policyAddCmd.Flags().StringVar(&policyFlags.owner, "owner", "", "edge to the owner used by the OwnerOnly rule")
+	policyCmd.AddCommand(policyAddCmd)
+	rootCmd.AddCommand(policyCmd)
+}
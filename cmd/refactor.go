@@ -0,0 +1,55 @@
+/*
+Copyright © 2021 MasseElch info@masseelch.de
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cmd
+
+import (
+	"github.com/masseelch/wapiti/wapiti"
+	"github.com/spf13/cobra"
+	"strings"
+)
+
+var extractSchemas []string
+
+// refactorCmd groups the commands changing several schemas at once.
+var refactorCmd = &cobra.Command{
+	Use:   "refactor",
+	Short: "Refactor the schemas",
+}
+
+// refactorExtractMixinCmd moves fields shared by several schemas into a mixin.
+var refactorExtractMixinCmd = &cobra.Command{
+	Use:   "extract-mixin <name> <field>[,<field>...]",
+	Short: "Move fields declared equally by several schemas into a new mixin",
+	Long: `Move fields declared equally by several schemas into a new mixin.
+
+The mixin is created in the schema package and added to the Mixin() method of every schema declaring the fields. The
+fields are removed from the Fields() methods of the schemas. Unless --schemas is given all schemas declaring the fields
+are changed.`,
+	Example: "wapiti refactor extract-mixin Audit created_at,updated_at --schemas Pet,User",
+	Args:    cobra.ExactArgs(2),
+	Run: func(cmd *cobra.Command, args []string) {
+		w, err := wapiti.New(cfg)
+		fatalOnErr(err)
+		fatalOnErr(w.ExtractMixin(args[0], strings.Split(args[1], ","), extractSchemas))
+	},
+}
+
+func init() {
+	refactorExtractMixinCmd.Flags().StringSliceVar(&extractSchemas, "schemas", nil, "schemas to extract the fields from")
+	refactorCmd.AddCommand(refactorExtractMixinCmd)
+	rootCmd.AddCommand(refactorCmd)
+}
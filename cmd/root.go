@@ -17,6 +17,7 @@ limitations under the License.
 package cmd
 
 import (
+	"errors"
 	"fmt"
 	"github.com/masseelch/wapiti/wapiti"
 	"github.com/masseelch/wapiti/wapiti/config"
@@ -45,10 +46,14 @@ func Execute() {
 
 func init() {
 	cfg = new(config.Config)
-	rootCmd.Flags().StringVar(&cfg.SchemaPath, "schema", "ent/schema", "/path/to/schema/dir")
+	rootCmd.PersistentFlags().StringVar(&cfg.SchemaPath, "schema", "ent/schema", "/path/to/schema/dir")
+	rootCmd.PersistentFlags().BoolVar(&cfg.DryRun, "dry-run", false, "print the changes as unified diff instead of writing them")
 }
 
 func fatalOnErr(err error) {
+	if errors.Is(err, wapiti.ErrNotWritten) {
+		return
+	}
 	if err != nil {
 		fmt.Printf("\n%s\n", err)
 		os.Exit(1)
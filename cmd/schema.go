@@ -0,0 +1,66 @@
+/*
+Copyright © 2021 MasseElch info@masseelch.de
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cmd
+
+import (
+	"github.com/masseelch/wapiti/wapiti"
+	"github.com/spf13/cobra"
+)
+
+// schemaCmd groups the commands working on whole schemas.
+var schemaCmd = &cobra.Command{
+	Use:   "schema",
+	Short: "Manage ent schemas",
+}
+
+// schemaCreateCmd creates a new schema.
+var schemaCreateCmd = &cobra.Command{
+	Use:     "create <name>",
+	Short:   "Create a new schema",
+	Example: "wapiti schema create Pet --id uuid",
+	Args:    cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		w, err := wapiti.New(cfg)
+		fatalOnErr(err)
+		fatalOnErr(w.CreateSchema(args[0], idType))
+	},
+}
+
+var (
+	idType    string
+	keepTable bool
+)
+
+// schemaRenameCmd renames a schema.
+var schemaRenameCmd = &cobra.Command{
+	Use:     "rename <old> <new>",
+	Short:   "Rename a schema and all references to it",
+	Example: "wapiti schema rename Pet Animal --keep-table",
+	Args:    cobra.ExactArgs(2),
+	Run: func(cmd *cobra.Command, args []string) {
+		w, err := wapiti.New(cfg)
+		fatalOnErr(err)
+		fatalOnErr(w.RenameSchema(args[0], args[1], keepTable))
+	},
+}
+
+func init() {
+	schemaCreateCmd.Flags().StringVar(&idType, "id", "int", "type of the ID field: int, uuid, string or int64")
+	schemaRenameCmd.Flags().BoolVar(&keepTable, "keep-table", false, "add an entsql.Annotation to keep the name of the database table")
+	schemaCmd.AddCommand(schemaCreateCmd, schemaRenameCmd)
+	rootCmd.AddCommand(schemaCmd)
+}
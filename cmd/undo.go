@@ -0,0 +1,48 @@
+/*
+Copyright © 2021 MasseElch info@masseelch.de
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cmd
+
+import (
+	"github.com/masseelch/wapiti/wapiti"
+	"github.com/spf13/cobra"
+)
+
+// undoCmd reverts the last change made by wapiti.
+var undoCmd = &cobra.Command{
+	Use:   "undo",
+	Short: "Undo the last change made by wapiti",
+	Long: "Undo the last change made by wapiti. The schemas are not loaded, so changes leaving code that does not " +
+		"compile can be undone.",
+	Args: cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		fatalOnErr(wapiti.UndoFiles(cfg))
+	},
+}
+
+// redoCmd re-applies the last change reverted by undo.
+var redoCmd = &cobra.Command{
+	Use:   "redo",
+	Short: "Redo the last change reverted by undo",
+	Args:  cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		fatalOnErr(wapiti.RedoFiles(cfg))
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(undoCmd, redoCmd)
+}
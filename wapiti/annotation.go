@@ -0,0 +1,389 @@
+package wapiti
+
+import (
+	"encoding/json"
+	"entgo.io/ent/dialect/entsql"
+	"entgo.io/ent/entc/load"
+	"fmt"
+	"github.com/c-bata/go-prompt"
+	"github.com/logrusorgru/aurora/v3"
+	"go/ast"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// referenceOptions maps the referential actions of entsql to the names of their constants.
+var referenceOptions = map[entsql.ReferenceOption]string{
+	entsql.NoAction:   "entsql.NoAction",
+	entsql.Restrict:   "entsql.Restrict",
+	entsql.Cascade:    "entsql.Cascade",
+	entsql.SetNull:    "entsql.SetNull",
+	entsql.SetDefault: "entsql.SetDefault",
+}
+
+// ParseReferenceOption parses a referential action like cascade or "SET NULL".
+func ParseReferenceOption(s string) (entsql.ReferenceOption, error) {
+	o := entsql.ReferenceOption(strings.ToUpper(strings.ReplaceAll(strings.TrimSpace(s), "_", " ")))
+	if _, ok := referenceOptions[o]; !ok && o != "" {
+		return "", fmt.Errorf("unknown referential action %q: choose from NO ACTION, RESTRICT, CASCADE, SET NULL and SET DEFAULT", s)
+	}
+	return o, nil
+}
+
+// annotationCode returns the literal of the annotation listing only the values that are set. Empty if no value is set.
+func annotationCode(a *entsql.Annotation) (string, error) {
+	if a.Incremental != nil {
+		return "", fmt.Errorf("wapiti cannot write the Incremental option of entsql annotations")
+	}
+	var kvs []string
+	for _, kv := range []struct{ k, v string }{
+		{"Table", a.Table},
+		{"Charset", a.Charset},
+		{"Collation", a.Collation},
+		{"Options", a.Options},
+		{"Default", a.Default},
+		{"Check", a.Check},
+	} {
+		if kv.v != "" {
+			kvs = append(kvs, kv.k+": "+strconv.Quote(kv.v))
+		}
+	}
+	if a.Size != 0 {
+		kvs = append(kvs, fmt.Sprintf("Size: %d", a.Size))
+	}
+	if a.OnDelete != "" {
+		c, ok := referenceOptions[a.OnDelete]
+		if !ok {
+			return "", fmt.Errorf("unknown referential action %q", a.OnDelete)
+		}
+		kvs = append(kvs, "OnDelete: "+c)
+	}
+	if len(a.Checks) > 0 {
+		ks := make([]string, 0, len(a.Checks))
+		for k := range a.Checks {
+			ks = append(ks, k)
+		}
+		sort.Strings(ks)
+		cs := make([]string, len(ks))
+		for i, k := range ks {
+			cs[i] = fmt.Sprintf("%q: %q", k, a.Checks[k])
+		}
+		kvs = append(kvs, "Checks: map[string]string{"+strings.Join(cs, ", ")+"}")
+	}
+	if len(kvs) == 0 {
+		return "", nil
+	}
+	return "entsql.Annotation{" + strings.Join(kvs, ", ") + "}", nil
+}
+
+// SQLAnnotation returns the entsql annotation of the loaded annotations. A zero annotation if there is none.
+func SQLAnnotation(as map[string]interface{}) (*entsql.Annotation, error) {
+	a := &entsql.Annotation{}
+	v, ok := as[a.Name()]
+	if !ok {
+		return a, nil
+	}
+	b, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(b, a); err != nil {
+		return nil, fmt.Errorf("decoding %s annotation: %w", a.Name(), err)
+	}
+	return a, nil
+}
+
+// DescribeAnnotations returns the loaded annotations in a human readable form, one per line.
+func DescribeAnnotations(as map[string]interface{}) []string {
+	ns := make([]string, 0, len(as))
+	for n := range as {
+		ns = append(ns, n)
+	}
+	sort.Strings(ns)
+	ds := make([]string, len(ns))
+	for i, n := range ns {
+		b, err := json.Marshal(as[n])
+		if err != nil {
+			b = []byte(fmt.Sprint(as[n]))
+		}
+		ds[i] = n + " " + string(b)
+	}
+	return ds
+}
+
+// AnnotateSchema sets the entsql annotation of the schema. An existing one is replaced, a zero annotation removes it.
+// Calls Reload afterwards.
+func (w *Wapiti) AnnotateSchema(s *load.Schema, a *entsql.Annotation) error {
+	code, err := annotationCode(a)
+	if err != nil {
+		return err
+	}
+	file, fn := w.method(s, "Annotations")
+	if fn == nil {
+		if code == "" {
+			return nil
+		}
+		return w.appendToMethod(s, "Annotations", annotationsTpl, "[]schema.Annotation", code, nil)
+	}
+	src, err := w.source(file)
+	if err != nil {
+		return err
+	}
+	ret, err := src.returned(src.method(s.Name, "Annotations"))
+	if err != nil {
+		return err
+	}
+	lit, ok := ret.(*ast.CompositeLit)
+	i := -1
+	if ok {
+		for j, e := range lit.Elts {
+			if isSQLAnnotation(e) {
+				i = j
+			}
+		}
+	}
+	switch {
+	case i >= 0 && code == "":
+		err = src.removeElem(lit, i)
+	case i >= 0:
+		err = src.replaceElem(lit, i, code)
+	case code == "":
+		return nil
+	default:
+		err = src.appendElem(src.method(s.Name, "Annotations"), "[]schema.Annotation", code)
+	}
+	if err != nil {
+		return err
+	}
+	return w.save(src)
+}
+
+// AnnotateEdge sets the entsql annotation of the edge with the given name of the schema. Calls Reload afterwards.
+func (w *Wapiti) AnnotateEdge(s *load.Schema, name string, a *entsql.Annotation) error {
+	file, _ := w.method(s, "Edges")
+	if file == nil {
+		return fmt.Errorf("schema %s has no Edges() method", s.Name)
+	}
+	src, err := w.source(file)
+	if err != nil {
+		return err
+	}
+	lit, i := src.elem(src.method(s.Name, "Edges"), "edge", name)
+	if i < 0 {
+		return fmt.Errorf("edge %s is not declared in %s.Edges()", name, s.Name)
+	}
+	return w.annotateElem(src, lit.Elts[i], a)
+}
+
+// AnnotateField sets the entsql annotation of the field with the given name of the schema. Calls Reload afterwards.
+func (w *Wapiti) AnnotateField(s *load.Schema, name string, a *entsql.Annotation) error {
+	src, lit, i, err := w.fieldElem(s, name)
+	if err != nil {
+		return err
+	}
+	return w.annotateElem(src, lit.Elts[i], a)
+}
+
+// annotateElem sets the entsql annotation of the builder chain e and saves the source.
+func (w *Wapiti) annotateElem(src *source, e ast.Expr, a *entsql.Annotation) error {
+	code, err := annotationCode(a)
+	if err != nil {
+		return err
+	}
+	if err := src.apply(annotationEdits(e, code)...); err != nil {
+		return err
+	}
+	return w.save(src)
+}
+
+// annotationEdits returns the edits setting the entsql annotation of the builder chain e to code. An empty code
+// removes the annotation.
+func annotationEdits(e ast.Expr, code string) []edit {
+	calls := chain(e)
+	for _, c := range calls[1:] {
+		sel := c.Fun.(*ast.SelectorExpr)
+		if sel.Sel.Name != "Annotations" {
+			continue
+		}
+		for i, a := range c.Args {
+			if !isSQLAnnotation(a) {
+				continue
+			}
+			switch {
+			case code != "":
+				return []edit{{pos: a.Pos(), end: a.End(), text: code}}
+			case len(c.Args) == 1:
+				// Remove the whole option.
+				return []edit{{pos: sel.X.End(), end: c.End()}}
+			case i == 0:
+				return []edit{{pos: a.Pos(), end: c.Args[1].Pos()}}
+			default:
+				return []edit{{pos: c.Args[i-1].End(), end: a.End()}}
+			}
+		}
+	}
+	if code == "" {
+		return nil
+	}
+	return []edit{{pos: e.End(), end: e.End(), text: ".Annotations(" + code + ")"}}
+}
+
+// isSQLAnnotation reports whether e is an entsql.Annotation literal.
+func isSQLAnnotation(e ast.Expr) bool {
+	lit, ok := e.(*ast.CompositeLit)
+	if !ok {
+		return false
+	}
+	sel, ok := lit.Type.(*ast.SelectorExpr)
+	if !ok || sel.Sel.Name != "Annotation" {
+		return false
+	}
+	x, ok := sel.X.(*ast.Ident)
+	return ok && x.Name == "entsql"
+}
+
+// annotationActions are the things the entsql annotation can be set on.
+var annotationActions = []prompt.Suggest{
+	{Text: "schema", Description: "Table name, charset, collation and options of the table"},
+	{Text: "edge", Description: "Referential action on deletion of the foreign key"},
+	{Text: "field", Description: "Column size and default"},
+}
+
+// referenceOptionSuggestions describe the referential actions of entsql.
+var referenceOptionSuggestions = []prompt.Suggest{
+	{Text: "CASCADE", Description: "Delete the rows referencing the deleted one"},
+	{Text: "SET_NULL", Description: "Set the foreign key of the rows referencing the deleted one to NULL"},
+	{Text: "SET_DEFAULT", Description: "Set the foreign key of the rows referencing the deleted one to its default"},
+	{Text: "RESTRICT", Description: "Forbid to delete a referenced row"},
+	{Text: "NO_ACTION", Description: "Like RESTRICT but checked at the end of the transaction"},
+}
+
+// ManageAnnotations lists the annotations of the given node, its edges and fields and lets the user set the entsql
+// annotations until the user chooses to go on.
+func (w *Wapiti) ManageAnnotations(s *load.Schema) error {
+	for {
+		printAnnotations(s)
+		var err error
+		switch a := ask(append(annotationActions, shortcuts...), "Set SQL annotations (schema/edge/field) (press <return> to go on):"); a {
+		case "":
+			return nil
+		case "schema":
+			err = w.annotateSchema(s)
+		case "edge":
+			err = w.annotateEdge(s)
+		case "field":
+			err = w.annotateField(s)
+		default:
+			if !w.shortcut(a) {
+				err = fmt.Errorf("unknown action %q", a)
+			}
+		}
+		if err != nil && !w.skip(err) {
+			return err
+		}
+		// The node is gone if the user undid its creation.
+		if s = w.LookupNode(s.Name); s == nil {
+			return nil
+		}
+	}
+}
+
+// printAnnotations prints the annotations of the node, its edges and its fields. Prints nothing if there are none.
+func printAnnotations(s *load.Schema) {
+	var ls []string
+	for _, d := range DescribeAnnotations(s.Annotations) {
+		ls = append(ls, "  "+d)
+	}
+	for _, e := range s.Edges {
+		for _, d := range DescribeAnnotations(e.Annotations) {
+			ls = append(ls, fmt.Sprintf("  edge %s: %s", e.Name, d))
+		}
+	}
+	for _, f := range s.Fields {
+		for _, d := range DescribeAnnotations(f.Annotations) {
+			ls = append(ls, fmt.Sprintf("  field %s: %s", f.Name, d))
+		}
+	}
+	if len(ls) == 0 {
+		return
+	}
+	fmt.Println(aurora.Cyan(fmt.Sprintf("\nAnnotations of %s:", s.Name)))
+	fmt.Println(strings.Join(ls, "\n"))
+}
+
+// annotateSchema asks for the table options of the node.
+func (w *Wapiti) annotateSchema(s *load.Schema) error {
+	a, err := SQLAnnotation(s.Annotations)
+	if err != nil {
+		return err
+	}
+	a.Table = askString(a.Table, "Table name")
+	a.Charset = askString(a.Charset, "Charset, e.g. utf8mb4")
+	a.Collation = askString(a.Collation, "Collation, e.g. utf8mb4_bin")
+	a.Options = askString(a.Options, "Additional table options, e.g. ENGINE = INNODB")
+	if err := w.AnnotateSchema(s, a); err != nil {
+		return err
+	}
+	fmt.Printf(annotatedFormat, aurora.Cyan(s.Name))
+	return nil
+}
+
+// annotateEdge asks for the edge to annotate and the referential action on deletion.
+func (w *Wapiti) annotateEdge(s *load.Schema) error {
+	var sgst []prompt.Suggest
+	for _, e := range s.Edges {
+		sgst = append(sgst, prompt.Suggest{Text: e.Name, Description: e.Type})
+	}
+	n := ask(sgst, "Name of the edge to annotate:")
+	e := w.lookupEdge(s.Name, n)
+	if e == nil {
+		return fmt.Errorf("unknown edge %q", n)
+	}
+	a, err := SQLAnnotation(e.Annotations)
+	if err != nil {
+		return err
+	}
+	o, err := ParseReferenceOption(askString(string(a.OnDelete), "Referential action on deletion"))
+	if err != nil {
+		return err
+	}
+	a.OnDelete = o
+	if err := w.AnnotateEdge(s, n, a); err != nil {
+		return err
+	}
+	fmt.Printf(annotatedFormat, aurora.Sprintf("edge %s of %s", aurora.Cyan(n), aurora.Cyan(s.Name)))
+	return nil
+}
+
+// annotateField asks for the field to annotate and its column size and default.
+func (w *Wapiti) annotateField(s *load.Schema) error {
+	var sgst []prompt.Suggest
+	for _, f := range s.Fields {
+		sgst = append(sgst, prompt.Suggest{Text: f.Name, Description: typeName(f)})
+	}
+	n := ask(sgst, "Name of the field to annotate:")
+	f := w.lookupField(s.Name, n)
+	if f == nil {
+		return fmt.Errorf("unknown field %q", n)
+	}
+	a, err := SQLAnnotation(f.Annotations)
+	if err != nil {
+		return err
+	}
+	var size string
+	if a.Size != 0 {
+		size = strconv.FormatInt(a.Size, 10)
+	}
+	if size = askString(size, "Column size"); size == "" {
+		a.Size = 0
+	} else if a.Size, err = strconv.ParseInt(size, 10, 64); err != nil || a.Size <= 0 {
+		return fmt.Errorf("invalid size %q", size)
+	}
+	a.Default = askString(a.Default, "Column default as SQL expression, e.g. CURRENT_TIMESTAMP")
+	if err := w.AnnotateField(s, n, a); err != nil {
+		return err
+	}
+	fmt.Printf(annotatedFormat, aurora.Sprintf("field %s of %s", aurora.Cyan(n), aurora.Cyan(s.Name)))
+	return nil
+}
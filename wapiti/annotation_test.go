@@ -0,0 +1,103 @@
+package wapiti
+
+import (
+	"entgo.io/ent/dialect/entsql"
+	"github.com/stretchr/testify/require"
+	"go/ast"
+	"testing"
+)
+
+func TestAnnotationCode(t *testing.T) {
+	for _, tc := range []struct {
+		a    *entsql.Annotation
+		code string
+	}{
+		{&entsql.Annotation{}, ""},
+		{&entsql.Annotation{Table: "users", Charset: "utf8mb4"}, `entsql.Annotation{Table: "users", Charset: "utf8mb4"}`},
+		{&entsql.Annotation{OnDelete: entsql.Cascade}, `entsql.Annotation{OnDelete: entsql.Cascade}`},
+		{&entsql.Annotation{Size: 64, Default: "CURRENT_TIMESTAMP"}, `entsql.Annotation{Default: "CURRENT_TIMESTAMP", Size: 64}`},
+		{&entsql.Annotation{Checks: map[string]string{"b": "x > 0", "a": "y > 0"}}, `entsql.Annotation{Checks: map[string]string{"a": "y > 0", "b": "x > 0"}}`},
+	} {
+		c, err := annotationCode(tc.a)
+		require.NoError(t, err)
+		require.Equal(t, tc.code, c)
+	}
+	_, err := annotationCode(&entsql.Annotation{OnDelete: "DROP"})
+	require.Error(t, err)
+}
+
+func TestParseReferenceOption(t *testing.T) {
+	for in, out := range map[string]entsql.ReferenceOption{
+		"cascade":  entsql.Cascade,
+		"set_null": entsql.SetNull,
+		"SET NULL": entsql.SetNull,
+		"":         "",
+	} {
+		o, err := ParseReferenceOption(in)
+		require.NoError(t, err)
+		require.Equal(t, out, o)
+	}
+	_, err := ParseReferenceOption("drop")
+	require.Error(t, err)
+}
+
+func TestSQLAnnotation(t *testing.T) {
+	a, err := SQLAnnotation(map[string]interface{}{
+		"EntSQL": map[string]interface{}{"table": "users", "on_delete": "CASCADE", "size": float64(10)},
+	})
+	require.NoError(t, err)
+	require.Equal(t, &entsql.Annotation{Table: "users", OnDelete: entsql.Cascade, Size: 10}, a)
+	a, err = SQLAnnotation(nil)
+	require.NoError(t, err)
+	require.Equal(t, &entsql.Annotation{}, a)
+	require.Equal(t, []string{`EntSQL {"table":"users"}`, `Other {"a":1}`}, DescribeAnnotations(map[string]interface{}{
+		"Other":  map[string]int{"a": 1},
+		"EntSQL": map[string]string{"table": "users"},
+	}))
+}
+
+const annotatedSrc = `package schema
+
+import (
+	"entgo.io/ent"
+	"entgo.io/ent/schema/edge"
+)
+
+type User struct {
+	ent.Schema
+}
+
+func (User) Edges() []ent.Edge {
+	return []ent.Edge{
+		edge.To("pets", Pet.Type),
+		edge.To("cars", Car.Type).Annotations(entsql.Annotation{OnDelete: entsql.Restrict}),
+		edge.To("groups", Group.Type).Annotations(other.Annotation{}, entsql.Annotation{OnDelete: entsql.Restrict}),
+	}
+}
+`
+
+func TestAnnotationEdits(t *testing.T) {
+	for _, tc := range []struct {
+		edge, code, out string
+	}{
+		{"pets", "entsql.Annotation{OnDelete: entsql.Cascade}", `edge.To("pets", Pet.Type).Annotations(entsql.Annotation{OnDelete: entsql.Cascade})`},
+		{"pets", "", `edge.To("pets", Pet.Type)`},
+		{"cars", "entsql.Annotation{OnDelete: entsql.Cascade}", `edge.To("cars", Car.Type).Annotations(entsql.Annotation{OnDelete: entsql.Cascade})`},
+		{"cars", "", `edge.To("cars", Car.Type)`},
+		{"groups", "", `edge.To("groups", Group.Type).Annotations(other.Annotation{})`},
+	} {
+		s, err := parseSource("user.go", []byte(annotatedSrc))
+		require.NoError(t, err)
+		lit, i := s.elem(s.method("User", "Edges"), "edge", tc.edge)
+		require.GreaterOrEqual(t, i, 0)
+		require.NoError(t, s.apply(annotationEdits(lit.Elts[i], tc.code)...))
+		lit, i = s.elem(s.method("User", "Edges"), "edge", tc.edge)
+		require.Equal(t, tc.out, s.text(lit.Elts[i]), tc.edge)
+	}
+}
+
+func TestIsSQLAnnotation(t *testing.T) {
+	require.True(t, isSQLAnnotation(&ast.CompositeLit{Type: &ast.SelectorExpr{X: ast.NewIdent("entsql"), Sel: ast.NewIdent("Annotation")}}))
+	require.False(t, isSQLAnnotation(&ast.CompositeLit{Type: &ast.SelectorExpr{X: ast.NewIdent("field"), Sel: ast.NewIdent("Annotation")}}))
+	require.False(t, isSQLAnnotation(ast.NewIdent("x")))
+}
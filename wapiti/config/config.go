@@ -2,4 +2,6 @@ package config
 
 type Config struct {
 	SchemaPath string
+	// DryRun prints the changes as unified diff instead of writing them.
+	DryRun bool
 }
@@ -0,0 +1,943 @@
+package wapiti
+
+import (
+	"fmt"
+	"strings"
+	"unicode"
+)
+
+// tokenKind is the kind of a token of a DDL script.
+type tokenKind int
+
+const (
+	tokWord   tokenKind = iota // keywords and unquoted identifiers
+	tokIdent                   // quoted identifiers like `name`, "name" and [name]
+	tokString                  // string literals like 'text' and $$text$$
+	tokNumber                  // numeric literals
+	tokPunct                   // punctuation like ( ) , ; and ::
+)
+
+// sqlToken is a token of a DDL script. The text of quoted identifiers and strings is unquoted.
+type sqlToken struct {
+	kind tokenKind
+	text string
+}
+
+// is reports whether the token is the given keyword or punctuation, ignoring case.
+func (t sqlToken) is(s string) bool {
+	return (t.kind == tokWord || t.kind == tokPunct) && strings.EqualFold(t.text, s)
+}
+
+// oneOf reports whether the token is one of the given keywords or punctuation.
+func (t sqlToken) oneOf(ss ...string) bool {
+	for _, s := range ss {
+		if t.is(s) {
+			return true
+		}
+	}
+	return false
+}
+
+// name reports whether the token can be used as name of a table or column.
+func (t sqlToken) name() bool {
+	return t.kind == tokWord || t.kind == tokIdent || t.kind == tokString
+}
+
+// lexDDL splits the DDL script into tokens. Comments are dropped.
+func lexDDL(src string) ([]sqlToken, error) {
+	var ts []sqlToken
+	rs := []rune(src)
+	for i := 0; i < len(rs); {
+		r := rs[i]
+		switch {
+		case unicode.IsSpace(r):
+			i++
+		case r == '-' && i+1 < len(rs) && rs[i+1] == '-', r == '#':
+			for i < len(rs) && rs[i] != '\n' {
+				i++
+			}
+		case r == '/' && i+1 < len(rs) && rs[i+1] == '*':
+			end := indexRunes(rs[i+2:], []rune("*/"))
+			if end < 0 {
+				return nil, fmt.Errorf("unterminated comment")
+			}
+			i += 2 + end + 2
+		case r == '\'' || r == '"' || r == '`':
+			s, n, err := lexQuoted(rs[i:], r)
+			if err != nil {
+				return nil, err
+			}
+			k := tokIdent
+			if r == '\'' {
+				k = tokString
+			}
+			ts = append(ts, sqlToken{kind: k, text: s})
+			i += n
+		case r == '[' && i+1 < len(rs) && rs[i+1] != ']':
+			end := indexRunes(rs[i:], []rune("]"))
+			if end < 0 {
+				return nil, fmt.Errorf("unterminated identifier")
+			}
+			ts = append(ts, sqlToken{kind: tokIdent, text: string(rs[i+1 : i+end])})
+			i += end + 1
+		case r == '$' && i+1 < len(rs) && (rs[i+1] == '$' || unicode.IsLetter(rs[i+1])):
+			// Dollar-quoted strings of PostgreSQL like $$text$$ or $tag$text$tag$.
+			end := indexRunes(rs[i+1:], []rune("$"))
+			if end < 0 {
+				return nil, fmt.Errorf("unterminated dollar-quoted string")
+			}
+			tag := rs[i : i+end+2]
+			body := rs[i+len(tag):]
+			end = indexRunes(body, tag)
+			if end < 0 {
+				return nil, fmt.Errorf("unterminated dollar-quoted string")
+			}
+			ts = append(ts, sqlToken{kind: tokString, text: string(body[:end])})
+			i += 2*len(tag) + end
+		case unicode.IsDigit(r) || r == '.' && i+1 < len(rs) && unicode.IsDigit(rs[i+1]):
+			j := i
+			for j < len(rs) && (unicode.IsDigit(rs[j]) || rs[j] == '.' || unicode.IsLetter(rs[j]) ||
+				(rs[j] == '-' || rs[j] == '+') && (rs[j-1] == 'e' || rs[j-1] == 'E')) {
+				j++
+			}
+			ts = append(ts, sqlToken{kind: tokNumber, text: string(rs[i:j])})
+			i = j
+		case unicode.IsLetter(r) || r == '_':
+			j := i
+			for j < len(rs) && (unicode.IsLetter(rs[j]) || unicode.IsDigit(rs[j]) || rs[j] == '_' || rs[j] == '$') {
+				j++
+			}
+			// MySQL bit and hex literals like b'1' and x'ff'.
+			if j == i+1 && j < len(rs) && rs[j] == '\'' && strings.ContainsRune("bBxX", r) {
+				s, n, err := lexQuoted(rs[j:], '\'')
+				if err != nil {
+					return nil, err
+				}
+				ts = append(ts, sqlToken{kind: tokNumber, text: string(r) + "'" + s + "'"})
+				i = j + n
+				continue
+			}
+			ts = append(ts, sqlToken{kind: tokWord, text: string(rs[i:j])})
+			i = j
+		case (r == ':' || r == '|') && i+1 < len(rs) && rs[i+1] == r:
+			ts = append(ts, sqlToken{kind: tokPunct, text: string(rs[i : i+2])})
+			i += 2
+		default:
+			ts = append(ts, sqlToken{kind: tokPunct, text: string(r)})
+			i++
+		}
+	}
+	return ts, nil
+}
+
+// lexQuoted returns the unquoted text of the string or identifier quoted by q at the start of rs and the number of
+// runes it spans. Quotes are escaped by doubling them or, in strings, by a backslash.
+func lexQuoted(rs []rune, q rune) (string, int, error) {
+	var b strings.Builder
+	for i := 1; i < len(rs); i++ {
+		switch {
+		case rs[i] == '\\' && q == '\'' && i+1 < len(rs):
+			i++
+			switch rs[i] {
+			case 'n':
+				b.WriteRune('\n')
+			case 't':
+				b.WriteRune('\t')
+			default:
+				b.WriteRune(rs[i])
+			}
+		case rs[i] == q && i+1 < len(rs) && rs[i+1] == q:
+			b.WriteRune(q)
+			i++
+		case rs[i] == q:
+			return b.String(), i + 1, nil
+		default:
+			b.WriteRune(rs[i])
+		}
+	}
+	return "", 0, fmt.Errorf("unterminated %c", q)
+}
+
+// indexRunes returns the index of the first occurrence of sub in rs. -1 if there is none.
+func indexRunes(rs, sub []rune) int {
+	for i := 0; i+len(sub) <= len(rs); i++ {
+		if string(rs[i:i+len(sub)]) == string(sub) {
+			return i
+		}
+	}
+	return -1
+}
+
+// ddl is the database schema described by a DDL script.
+type ddl struct {
+	tables []*table
+	// enums holds the values of the enum types created by the script, keyed by type name.
+	enums map[string][]string
+	// dialect is the dialect the script is most likely written in. Empty if unknown.
+	dialect string
+}
+
+// table is a table created by a DDL script.
+type table struct {
+	name    string
+	columns []*column
+	pk      []string
+	fks     []*foreignKey
+	indexes []*tableIndex
+}
+
+// column is a column of a table.
+type column struct {
+	name string
+	// typ is the lower-cased type name like varchar or double precision, raw the type as written, e.g. VARCHAR(64).
+	typ, raw      string
+	args          []string
+	unsigned      bool
+	array         bool
+	notNull       bool
+	pk            bool
+	unique        bool
+	autoIncrement bool
+	// def is the default value. Strings are unquoted, expressions are given as written.
+	def       string
+	hasDef    bool
+	defString bool
+	// updateNow is set if the column is set to the current time on updates.
+	updateNow bool
+	comment   string
+}
+
+// foreignKey is a foreign-key constraint of a table.
+type foreignKey struct {
+	columns    []string
+	table      string
+	refColumns []string
+	onDelete   string
+}
+
+// tableIndex is an index or unique constraint of a table.
+type tableIndex struct {
+	name    string
+	columns []string
+	unique  bool
+}
+
+// column returns the column with the given name. nil if there is no such column.
+func (t *table) column(name string) *column {
+	for _, c := range t.columns {
+		if strings.EqualFold(c.name, name) {
+			return c
+		}
+	}
+	return nil
+}
+
+// table returns the table with the given name. nil if there is no such table.
+func (d *ddl) table(name string) *table {
+	for _, t := range d.tables {
+		if strings.EqualFold(t.name, name) {
+			return t
+		}
+	}
+	return nil
+}
+
+// parseDDL parses the CREATE TABLE, CREATE INDEX, CREATE TYPE ... AS ENUM, ALTER TABLE ... ADD and COMMENT ON COLUMN
+// statements of the DDL script. Other statements are ignored.
+func parseDDL(src string) (*ddl, error) {
+	ts, err := lexDDL(src)
+	if err != nil {
+		return nil, err
+	}
+	d := &ddl{enums: make(map[string][]string), dialect: detectDialect(ts)}
+	for _, stmt := range splitStatements(ts) {
+		p := &ddlParser{ts: stmt}
+		if err := p.statement(d); err != nil {
+			return nil, err
+		}
+	}
+	return d, nil
+}
+
+// splitStatements splits the tokens at semicolons.
+func splitStatements(ts []sqlToken) [][]sqlToken {
+	var (
+		ss   [][]sqlToken
+		prev int
+	)
+	for i, t := range ts {
+		if t.is(";") {
+			if i > prev {
+				ss = append(ss, ts[prev:i])
+			}
+			prev = i + 1
+		}
+	}
+	if prev < len(ts) {
+		ss = append(ss, ts[prev:])
+	}
+	return ss
+}
+
+// detectDialect guesses the dialect of the script by the constructs specific to a dialect.
+func detectDialect(ts []sqlToken) string {
+	for i, t := range ts {
+		switch {
+		case t.oneOf("AUTO_INCREMENT", "ENGINE", "UNSIGNED"):
+			return "mysql"
+		case t.oneOf("::", "SERIAL", "BIGSERIAL", "BYTEA", "JSONB", "TIMESTAMPTZ", "VARYING"), t.is("IDENTITY") && i > 0 && ts[i-1].is("AS"):
+			return "postgres"
+		case t.is("AUTOINCREMENT"), t.is("WITHOUT") && i+1 < len(ts) && ts[i+1].is("ROWID"):
+			return "sqlite3"
+		}
+	}
+	return ""
+}
+
+// ddlParser parses a single statement of a DDL script.
+type ddlParser struct {
+	ts  []sqlToken
+	pos int
+}
+
+// peek returns the token at offset n from the current one. A zero token at the end of the statement.
+func (p *ddlParser) peek(n int) sqlToken {
+	if p.pos+n < len(p.ts) {
+		return p.ts[p.pos+n]
+	}
+	return sqlToken{kind: tokPunct}
+}
+
+// eof reports whether all tokens of the statement are consumed.
+func (p *ddlParser) eof() bool {
+	return p.pos >= len(p.ts)
+}
+
+// next consumes and returns the current token.
+func (p *ddlParser) next() sqlToken {
+	t := p.peek(0)
+	p.pos++
+	return t
+}
+
+// accept consumes the given sequence of keywords if the statement continues with it.
+func (p *ddlParser) accept(kws ...string) bool {
+	for i, kw := range kws {
+		if !p.peek(i).is(kw) {
+			return false
+		}
+	}
+	p.pos += len(kws)
+	return true
+}
+
+// expect consumes the given keyword or punctuation or returns an error.
+func (p *ddlParser) expect(kw string) error {
+	if !p.accept(kw) {
+		return p.errorf("expected %s", kw)
+	}
+	return nil
+}
+
+// errorf returns an error pointing to the current token.
+func (p *ddlParser) errorf(format string, args ...interface{}) error {
+	near := "end of statement"
+	if !p.eof() {
+		near = fmt.Sprintf("%q", p.peek(0).text)
+	}
+	return fmt.Errorf("parsing DDL: %s near %s", fmt.Sprintf(format, args...), near)
+}
+
+// name consumes a possibly qualified name like schema.table and returns its last part.
+func (p *ddlParser) name() (string, error) {
+	if !p.peek(0).name() {
+		return "", p.errorf("expected name")
+	}
+	n := p.next().text
+	for p.peek(0).is(".") && p.peek(1).name() {
+		p.pos++
+		n = p.next().text
+	}
+	return n, nil
+}
+
+// names consumes a parenthesized list of column names. Lengths and sort orders of index columns are skipped.
+func (p *ddlParser) names() ([]string, error) {
+	if err := p.expect("("); err != nil {
+		return nil, err
+	}
+	var ns []string
+	for {
+		if !p.peek(0).name() {
+			return nil, p.errorf("expected column name")
+		}
+		ns = append(ns, p.next().text)
+		// Skip everything up to the next column, e.g. (10) or DESC.
+		for depth := 0; !p.eof(); p.pos++ {
+			t := p.peek(0)
+			if depth == 0 && (t.is(",") || t.is(")")) {
+				break
+			}
+			if t.is("(") {
+				depth++
+			} else if t.is(")") {
+				depth--
+			}
+		}
+		if p.accept(")") {
+			return ns, nil
+		}
+		if err := p.expect(","); err != nil {
+			return nil, err
+		}
+	}
+}
+
+// skipGroup consumes a parenthesized group of tokens if the statement continues with one and returns its source.
+func (p *ddlParser) skipGroup() string {
+	if !p.peek(0).is("(") {
+		return ""
+	}
+	start := p.pos
+	for depth := 0; !p.eof(); {
+		t := p.next()
+		if t.is("(") {
+			depth++
+		} else if t.is(")") {
+			if depth--; depth == 0 {
+				break
+			}
+		}
+	}
+	return joinTokens(p.ts[start:p.pos])
+}
+
+// joinTokens returns the tokens as source.
+func joinTokens(ts []sqlToken) string {
+	var b strings.Builder
+	for i, t := range ts {
+		if i > 0 && !t.oneOf("(", ")", ",", "::", "[", "]") && !ts[i-1].oneOf("(", "::", "[") {
+			b.WriteByte(' ')
+		}
+		switch t.kind {
+		case tokString:
+			b.WriteString("'" + strings.ReplaceAll(t.text, "'", "''") + "'")
+		case tokIdent:
+			b.WriteString(`"` + t.text + `"`)
+		default:
+			b.WriteString(t.text)
+		}
+	}
+	return b.String()
+}
+
+// statement parses the statement into d.
+func (p *ddlParser) statement(d *ddl) error {
+	switch {
+	case p.accept("CREATE"):
+		p.accept("OR", "REPLACE")
+		for p.accept("TEMPORARY") || p.accept("TEMP") || p.accept("UNLOGGED") {
+		}
+		switch {
+		case p.accept("TABLE"):
+			return p.createTable(d)
+		case p.accept("UNIQUE", "INDEX"):
+			return p.createIndex(d, true)
+		case p.accept("INDEX"):
+			return p.createIndex(d, false)
+		case p.accept("TYPE"):
+			return p.createType(d)
+		}
+	case p.accept("ALTER", "TABLE"):
+		return p.alterTable(d)
+	case p.accept("COMMENT", "ON", "COLUMN"):
+		return p.commentOn(d)
+	}
+	return nil
+}
+
+// createTable parses the rest of a CREATE TABLE statement.
+func (p *ddlParser) createTable(d *ddl) error {
+	p.accept("IF", "NOT", "EXISTS")
+	n, err := p.name()
+	if err != nil {
+		return err
+	}
+	// CREATE TABLE ... AS SELECT and CREATE TABLE ... LIKE are not supported.
+	if !p.peek(0).is("(") {
+		return nil
+	}
+	t := &table{name: n}
+	p.pos++
+	for {
+		if err := p.tableItem(t); err != nil {
+			return err
+		}
+		if p.accept(")") {
+			break
+		}
+		if err := p.expect(","); err != nil {
+			return err
+		}
+	}
+	for _, c := range t.columns {
+		if c.pk {
+			t.pk = append(t.pk, c.name)
+		}
+	}
+	// A table may be created again, e.g. in a script holding migrations. The last one counts.
+	for i, o := range d.tables {
+		if strings.EqualFold(o.name, t.name) {
+			d.tables = append(d.tables[:i], d.tables[i+1:]...)
+			break
+		}
+	}
+	d.tables = append(d.tables, t)
+	return nil
+}
+
+// tableItem parses a column or constraint definition of a table.
+func (p *ddlParser) tableItem(t *table) error {
+	var symbol string
+	if p.accept("CONSTRAINT") {
+		if !p.peek(0).name() {
+			return p.errorf("expected constraint name")
+		}
+		symbol = p.next().text
+	}
+	switch {
+	case p.accept("PRIMARY", "KEY"):
+		cs, err := p.names()
+		if err != nil {
+			return err
+		}
+		t.pk = nil
+		for _, n := range cs {
+			if c := t.column(n); c != nil {
+				c.pk = true
+			}
+		}
+		p.skipItem()
+	case p.peek(0).is("UNIQUE") && (p.peek(1).oneOf("(", "KEY", "INDEX") || p.peek(2).is("(") && p.peek(3).name()):
+		p.pos++
+		_ = p.accept("KEY") || p.accept("INDEX")
+		if p.peek(0).name() {
+			symbol = p.next().text
+		}
+		cs, err := p.names()
+		if err != nil {
+			return err
+		}
+		t.indexes = append(t.indexes, &tableIndex{name: symbol, columns: cs, unique: true})
+		p.skipItem()
+	case p.peek(0).oneOf("KEY", "INDEX") && (p.peek(1).oneOf("(", "USING") || p.peek(2).is("(") && p.peek(3).name()):
+		p.pos++
+		if p.peek(0).name() && !p.peek(0).is("USING") {
+			symbol = p.next().text
+		}
+		p.accept("USING", "BTREE")
+		p.accept("USING", "HASH")
+		cs, err := p.names()
+		if err != nil {
+			return err
+		}
+		t.indexes = append(t.indexes, &tableIndex{name: symbol, columns: cs})
+		p.skipItem()
+	case p.accept("FOREIGN", "KEY"):
+		if p.peek(0).name() {
+			p.pos++
+		}
+		cs, err := p.names()
+		if err != nil {
+			return err
+		}
+		fk, err := p.references(cs)
+		if err != nil {
+			return err
+		}
+		t.fks = append(t.fks, fk)
+	case p.peek(0).oneOf("CHECK", "FULLTEXT", "SPATIAL", "EXCLUDE"):
+		p.skipItem()
+	default:
+		return p.columnDef(t)
+	}
+	return nil
+}
+
+// skipItem consumes the tokens up to the end of the current column or constraint definition.
+func (p *ddlParser) skipItem() {
+	for depth := 0; !p.eof(); p.pos++ {
+		t := p.peek(0)
+		if depth == 0 && (t.is(",") || t.is(")")) {
+			return
+		}
+		if t.is("(") {
+			depth++
+		} else if t.is(")") {
+			depth--
+		}
+	}
+}
+
+// references parses a REFERENCES clause of the given columns.
+func (p *ddlParser) references(cs []string) (*foreignKey, error) {
+	if err := p.expect("REFERENCES"); err != nil {
+		return nil, err
+	}
+	n, err := p.name()
+	if err != nil {
+		return nil, err
+	}
+	fk := &foreignKey{columns: cs, table: n}
+	if p.peek(0).is("(") {
+		if fk.refColumns, err = p.names(); err != nil {
+			return nil, err
+		}
+	}
+	for {
+		switch {
+		case p.accept("ON", "DELETE"):
+			fk.onDelete = p.referenceOption()
+		case p.accept("ON", "UPDATE"):
+			p.referenceOption()
+		case p.accept("MATCH"), p.accept("NOT", "DEFERRABLE"), p.accept("DEFERRABLE"), p.accept("INITIALLY"):
+			if p.peek(0).kind == tokWord && !p.peek(0).is("ON") {
+				p.pos++
+			}
+		default:
+			return fk, nil
+		}
+	}
+}
+
+// referenceOption parses a referential action like CASCADE or SET NULL.
+func (p *ddlParser) referenceOption() string {
+	switch {
+	case p.accept("SET", "NULL"):
+		return "SET NULL"
+	case p.accept("SET", "DEFAULT"):
+		return "SET DEFAULT"
+	case p.accept("NO", "ACTION"):
+		return "NO ACTION"
+	}
+	return strings.ToUpper(p.next().text)
+}
+
+// typeWords are the words continuing a type name like double precision or timestamp with time zone.
+var typeWords = map[string]bool{
+	"precision": true, "varying": true, "with": true, "without": true, "time": true, "zone": true,
+	"unsigned": true, "signed": true, "zerofill": true,
+}
+
+// columnDef parses a column definition.
+func (p *ddlParser) columnDef(t *table) error {
+	if !p.peek(0).name() {
+		return p.errorf("expected column name")
+	}
+	c := &column{name: p.next().text}
+	t.columns = append(t.columns, c)
+	// SQLite allows to leave out the type.
+	if p.peek(0).kind == tokWord && !isConstraintWord(p.peek(0)) {
+		start := p.pos
+		var words []string
+		for first := true; ; first = false {
+			tk := p.peek(0)
+			switch {
+			case tk.is("("):
+				p.pos++
+				for !p.eof() && !p.peek(0).is(")") {
+					if a := p.next(); !a.is(",") {
+						c.args = append(c.args, a.text)
+					}
+				}
+				if err := p.expect(")"); err != nil {
+					return err
+				}
+			case tk.is("[") && p.peek(1).is("]"):
+				p.pos += 2
+				c.array = true
+			case tk.kind == tokWord && (first || typeWords[strings.ToLower(tk.text)]):
+				p.pos++
+				switch w := strings.ToLower(tk.text); w {
+				case "unsigned", "zerofill":
+					c.unsigned = true
+				case "signed":
+				default:
+					words = append(words, w)
+				}
+			case tk.is("ARRAY") && !first:
+				p.pos++
+				c.array = true
+			default:
+				c.typ = strings.Join(words, " ")
+				c.raw = joinTokens(p.ts[start:p.pos])
+				c.autoIncrement = strings.HasSuffix(c.typ, "serial")
+				return p.columnConstraints(t, c)
+			}
+		}
+	}
+	return p.columnConstraints(t, c)
+}
+
+// isConstraintWord reports whether the token starts a column constraint instead of a type.
+func isConstraintWord(t sqlToken) bool {
+	return t.oneOf("PRIMARY", "NOT", "NULL", "UNIQUE", "DEFAULT", "REFERENCES", "CHECK", "CONSTRAINT", "COLLATE")
+}
+
+// columnConstraints parses the constraints following the type of a column.
+func (p *ddlParser) columnConstraints(t *table, c *column) error {
+	for !p.eof() && !p.peek(0).is(",") && !p.peek(0).is(")") {
+		switch {
+		case p.accept("CONSTRAINT"):
+			p.pos++
+		case p.accept("NOT", "NULL"):
+			c.notNull = true
+		case p.accept("NULL"):
+		case p.accept("PRIMARY", "KEY"):
+			c.pk, c.notNull = true, true
+			_ = p.accept("ASC") || p.accept("DESC")
+		case p.accept("UNIQUE"):
+			c.unique = true
+			p.accept("KEY")
+		case p.accept("AUTO_INCREMENT"), p.accept("AUTOINCREMENT"):
+			c.autoIncrement = true
+		case p.accept("DEFAULT"):
+			if err := p.defaultValue(c); err != nil {
+				return err
+			}
+		case p.accept("ON", "UPDATE"):
+			v := p.next()
+			p.skipGroup()
+			c.updateNow = isNow(v.text)
+		case p.accept("COMMENT"):
+			c.comment = p.next().text
+		case p.accept("COLLATE"), p.accept("CHARACTER", "SET"), p.accept("CHARSET"):
+			p.pos++
+		case p.peek(0).is("REFERENCES"):
+			fk, err := p.references([]string{c.name})
+			if err != nil {
+				return err
+			}
+			t.fks = append(t.fks, fk)
+		case p.accept("GENERATED"):
+			for !p.eof() && !p.peek(0).is(",") && !p.peek(0).is(")") && !p.peek(0).is("(") {
+				if p.next().is("IDENTITY") {
+					c.autoIncrement = true
+				}
+			}
+			p.skipGroup()
+			_ = p.accept("STORED") || p.accept("VIRTUAL")
+		case p.peek(0).is("("):
+			p.skipGroup()
+		default:
+			p.pos++
+			p.skipGroup()
+		}
+	}
+	return nil
+}
+
+// defaultValue parses the expression of a DEFAULT clause up to the next constraint of the column. Casts of
+// PostgreSQL like 'x'::character varying are dropped.
+func (p *ddlParser) defaultValue(c *column) error {
+	c.hasDef = true
+	var ts []sqlToken
+	for {
+		// An operand with optional signs, e.g. -1, 'text', now(), interval '1 day' or (1 + 2).
+		for len(ts) == 0 && p.peek(0).oneOf("-", "+") {
+			ts = append(ts, p.next())
+		}
+		if p.eof() || p.peek(0).kind == tokPunct && !p.peek(0).is("(") {
+			return p.errorf("expected default value")
+		}
+		start := p.pos
+		if !p.peek(0).is("(") {
+			// Typed literals like interval '1 day'.
+			if p.next().kind == tokWord && p.peek(0).kind == tokString {
+				p.pos++
+			}
+		}
+		p.skipGroup()
+		ts = append(ts, p.ts[start:p.pos]...)
+		for p.accept("::") {
+			for first := true; p.peek(0).kind == tokWord && (first || typeWords[strings.ToLower(p.peek(0).text)]); first = false {
+				p.pos++
+			}
+			p.skipGroup()
+		}
+		// Operators like + or || continue the expression.
+		if !isOperator(p.peek(0)) {
+			break
+		}
+		for isOperator(p.peek(0)) {
+			ts = append(ts, p.next())
+		}
+	}
+	for len(ts) > 2 && ts[0].is("(") && closing(ts) == len(ts)-1 {
+		ts = ts[1 : len(ts)-1]
+	}
+	switch {
+	case len(ts) == 1:
+		c.def, c.defString = ts[0].text, ts[0].kind == tokString
+	case len(ts) == 2 && ts[0].oneOf("-", "+") && ts[1].kind == tokNumber:
+		c.def = ts[0].text + ts[1].text
+	case len(ts) > 2 && ts[0].kind == tokWord && ts[1].is("(") && closing(ts[1:]) == len(ts)-2:
+		// Function calls like now() keep only their name.
+		c.def = ts[0].text + "()"
+	default:
+		// Other expressions are kept as written, they cannot be used as default in Go.
+		c.def = joinTokens(ts)
+	}
+	// The default of serial columns of PostgreSQL.
+	if strings.EqualFold(c.def, "nextval()") {
+		c.def, c.hasDef, c.autoIncrement = "", false, true
+	}
+	return nil
+}
+
+// isOperator reports whether the token is an operator of an expression, e.g. + or ||.
+func isOperator(t sqlToken) bool {
+	return t.kind == tokPunct && t.text != "" && strings.Contains("+-*/%|&^<>=!~", t.text[:1])
+}
+
+// closing returns the index of the parenthesis closing the one ts starts with. -1 if there is none.
+func closing(ts []sqlToken) int {
+	depth := 0
+	for i, t := range ts {
+		if t.is("(") {
+			depth++
+		} else if t.is(")") {
+			if depth--; depth == 0 {
+				return i
+			}
+		}
+	}
+	return -1
+}
+
+// isNow reports whether the SQL expression returns the current time.
+func isNow(v string) bool {
+	switch strings.ToUpper(strings.TrimSuffix(v, "()")) {
+	case "CURRENT_TIMESTAMP", "NOW", "LOCALTIMESTAMP", "CURRENT_DATE", "CURRENT_TIME", "LOCALTIME":
+		return true
+	}
+	return false
+}
+
+// createIndex parses the rest of a CREATE INDEX statement.
+func (p *ddlParser) createIndex(d *ddl, unique bool) error {
+	p.accept("CONCURRENTLY")
+	p.accept("IF", "NOT", "EXISTS")
+	var name string
+	if !p.peek(0).is("ON") {
+		n, err := p.name()
+		if err != nil {
+			return err
+		}
+		name = n
+	}
+	if err := p.expect("ON"); err != nil {
+		return err
+	}
+	p.accept("ONLY")
+	tn, err := p.name()
+	if err != nil {
+		return err
+	}
+	if p.accept("USING") {
+		p.pos++
+	}
+	cs, err := p.names()
+	if err != nil {
+		return err
+	}
+	if t := d.table(tn); t != nil {
+		t.indexes = append(t.indexes, &tableIndex{name: name, columns: cs, unique: unique})
+	}
+	return nil
+}
+
+// createType parses the rest of a CREATE TYPE statement. Only enum types are recorded.
+func (p *ddlParser) createType(d *ddl) error {
+	n, err := p.name()
+	if err != nil {
+		return err
+	}
+	if !p.accept("AS", "ENUM") {
+		return nil
+	}
+	if err := p.expect("("); err != nil {
+		return err
+	}
+	var vs []string
+	for !p.eof() && !p.peek(0).is(")") {
+		if t := p.next(); t.kind == tokString {
+			vs = append(vs, t.text)
+		}
+	}
+	d.enums[strings.ToLower(n)] = vs
+	return nil
+}
+
+// alterTable parses the ADD clauses of an ALTER TABLE statement.
+func (p *ddlParser) alterTable(d *ddl) error {
+	p.accept("IF", "EXISTS")
+	p.accept("ONLY")
+	n, err := p.name()
+	if err != nil {
+		return err
+	}
+	t := d.table(n)
+	if t == nil {
+		return nil
+	}
+	for !p.eof() {
+		if p.accept("ADD") {
+			p.accept("COLUMN")
+			p.accept("IF", "NOT", "EXISTS")
+			if err := p.tableItem(t); err != nil {
+				return err
+			}
+		}
+		p.skipItem()
+		if !p.accept(",") {
+			p.pos++
+		}
+	}
+	for _, c := range t.columns {
+		if c.pk && !containsFold(t.pk, c.name) {
+			t.pk = append(t.pk, c.name)
+		}
+	}
+	return nil
+}
+
+// commentOn parses the rest of a COMMENT ON COLUMN statement.
+func (p *ddlParser) commentOn(d *ddl) error {
+	var parts []string
+	for p.peek(0).name() {
+		parts = append(parts, p.next().text)
+		if !p.accept(".") {
+			break
+		}
+	}
+	if len(parts) < 2 || !p.accept("IS") {
+		return nil
+	}
+	if t := d.table(parts[len(parts)-2]); t != nil {
+		if c := t.column(parts[len(parts)-1]); c != nil && p.peek(0).kind == tokString {
+			c.comment = p.next().text
+		}
+	}
+	return nil
+}
+
+// containsFold reports whether ss contains s, ignoring case.
+func containsFold(ss []string, s string) bool {
+	for _, e := range ss {
+		if strings.EqualFold(e, s) {
+			return true
+		}
+	}
+	return false
+}
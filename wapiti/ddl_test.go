@@ -0,0 +1,93 @@
+package wapiti
+
+import (
+	"github.com/stretchr/testify/require"
+	"testing"
+)
+
+func TestLexDDL(t *testing.T) {
+	ts, err := lexDDL("CREATE TABLE `a``b` (\"c\" int DEFAULT 'it''s', [d] text[] DEFAULT b'1') -- comment\n/* block */ $$x;y$$ 1.5e-3 ::")
+	require.NoError(t, err)
+	require.Equal(t, []sqlToken{
+		{tokWord, "CREATE"}, {tokWord, "TABLE"}, {tokIdent, "a`b"}, {tokPunct, "("},
+		{tokIdent, "c"}, {tokWord, "int"}, {tokWord, "DEFAULT"}, {tokString, "it's"}, {tokPunct, ","},
+		{tokIdent, "d"}, {tokWord, "text"}, {tokPunct, "["}, {tokPunct, "]"}, {tokWord, "DEFAULT"}, {tokNumber, "b'1'"},
+		{tokPunct, ")"}, {tokString, "x;y"}, {tokNumber, "1.5e-3"}, {tokPunct, "::"},
+	}, ts)
+	for _, src := range []string{"'open", "/* open", "$$open"} {
+		_, err := lexDDL(src)
+		require.Error(t, err, src)
+	}
+}
+
+func TestParseDDL(t *testing.T) {
+	d, err := parseDDL(`
+CREATE TYPE mood AS ENUM ('happy', 'sad');
+CREATE TABLE IF NOT EXISTS public.users (
+	id bigint unsigned NOT NULL AUTO_INCREMENT,
+	name character varying(64) NOT NULL DEFAULT 'x'::character varying,
+	score double precision DEFAULT (-1),
+	seen timestamp with time zone DEFAULT now() ON UPDATE CURRENT_TIMESTAMP,
+	mood mood,
+	key varchar(10) UNIQUE COMMENT 'k',
+	PRIMARY KEY (id),
+	KEY users_name (name(10) DESC, score),
+	CONSTRAINT c CHECK (score > 0)
+);
+CREATE TABLE pets (id serial PRIMARY KEY, owner_id bigint REFERENCES users ON DELETE SET NULL);
+CREATE UNIQUE INDEX pets_owner ON pets (owner_id);
+ALTER TABLE ONLY pets ADD CONSTRAINT fk FOREIGN KEY (owner_id) REFERENCES users(id), ADD COLUMN age int;
+COMMENT ON COLUMN public.users.name IS 'The name';
+INSERT INTO users VALUES (1);
+`)
+	require.NoError(t, err)
+	require.Equal(t, "mysql", d.dialect)
+	require.Equal(t, []string{"happy", "sad"}, d.enums["mood"])
+	require.Len(t, d.tables, 2)
+
+	u := d.tables[0]
+	require.Equal(t, "users", u.name)
+	require.Equal(t, []string{"id"}, u.pk)
+	require.Equal(t, []*tableIndex{{name: "users_name", columns: []string{"name", "score"}}}, u.indexes)
+	require.Equal(t, &column{name: "id", typ: "bigint", raw: "bigint unsigned", unsigned: true, notNull: true, pk: true, autoIncrement: true}, u.column("id"))
+	require.Equal(t, &column{name: "name", typ: "character varying", raw: "character varying(64)", args: []string{"64"}, notNull: true, def: "x", hasDef: true, defString: true, comment: "The name"}, u.column("name"))
+	require.Equal(t, "-1", u.column("score").def)
+	require.Equal(t, "timestamp with time zone", u.column("seen").typ)
+	require.Equal(t, "now()", u.column("seen").def)
+	require.True(t, u.column("seen").updateNow)
+	require.Equal(t, "mood", u.column("mood").typ)
+	require.True(t, u.column("key").unique)
+	require.Equal(t, "k", u.column("key").comment)
+
+	p := d.tables[1]
+	require.Equal(t, []string{"id"}, p.pk)
+	require.True(t, p.column("id").autoIncrement)
+	require.Equal(t, "int", p.column("age").typ)
+	require.Equal(t, []*foreignKey{
+		{columns: []string{"owner_id"}, table: "users", onDelete: "SET NULL"},
+		{columns: []string{"owner_id"}, table: "users", refColumns: []string{"id"}},
+	}, p.fks)
+	require.Equal(t, []*tableIndex{{name: "pets_owner", columns: []string{"owner_id"}, unique: true}}, p.indexes)
+
+	_, err = parseDDL("CREATE TABLE t (a int, PRIMARY KEY a)")
+	require.EqualError(t, err, `parsing DDL: expected ( near "a"`)
+	d, err = parseDDL(`CREATE TABLE t (
+	a int DEFAULT 1+2 NOT NULL,
+	b text DEFAULT 'a' || 'b',
+	c int DEFAULT - 1,
+	d timestamp DEFAULT now() - interval '1 day' ON UPDATE now(),
+	e int DEFAULT (1) * -2
+)`)
+	require.NoError(t, err)
+	for _, tt := range []struct{ name, def string }{
+		{"a", "1 + 2"}, {"b", "'a' || 'b'"}, {"c", "-1"}, {"d", "now() - interval '1 day'"}, {"e", "(1) * - 2"},
+	} {
+		require.Equal(t, tt.def, d.tables[0].column(tt.name).def, tt.name)
+	}
+	require.True(t, d.tables[0].column("a").notNull)
+	require.True(t, d.tables[0].column("d").updateNow)
+	for _, src := range []string{"CREATE TABLE t (a int DEFAULT +", "CREATE TABLE t (a int DEFAULT", "CREATE TABLE t (a int DEFAULT 1 +"} {
+		_, err = parseDDL(src)
+		require.EqualError(t, err, "parsing DDL: expected default value near end of statement", src)
+	}
+}
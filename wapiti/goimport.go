@@ -0,0 +1,700 @@
+package wapiti
+
+import (
+	"fmt"
+	"go/ast"
+	"go/parser"
+	"go/token"
+	gotypes "go/types"
+	"os"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// gormModel holds the fields gorm.Model adds to the structs embedding it.
+const gormModel = `struct {
+	ID        uint ` + "`gorm:\"primarykey\"`" + `
+	CreatedAt time.Time
+	UpdatedAt time.Time
+	DeletedAt gorm.DeletedAt ` + "`gorm:\"index\"`" + `
+}`
+
+// ImportGo parses the struct types of the Go package in dir, e.g. GORM models, and returns the SpecFile describing them
+// as ent schemas. Fields are mapped by their Go type and their gorm, db and json tags. Belongs-to, has-one and has-many
+// relations become O2M or O2O edges, many2many relations M2M edges. Embedded structs are flattened into the structs
+// embedding them. The returned notes list the parts of the models that cannot be expressed by ent.
+func ImportGo(dir string) (*SpecFile, []string, error) {
+	fset := token.NewFileSet()
+	pkgs, err := parser.ParseDir(fset, dir, func(fi os.FileInfo) bool {
+		return !strings.HasSuffix(fi.Name(), "_test.go")
+	}, parser.ParseComments)
+	if err != nil {
+		return nil, nil, err
+	}
+	var files []string
+	byName := make(map[string]*ast.File)
+	for _, p := range pkgs {
+		for n, f := range p.Files {
+			files = append(files, n)
+			byName[n] = f
+		}
+	}
+	sort.Strings(files)
+	im := &goImporter{
+		structs: make(map[string]*ast.StructType),
+		basic:   make(map[string]string),
+		tables:  make(map[string]string),
+		models:  make(map[string]*goModel),
+		names:   make(map[*SpecSchema]map[string]bool),
+		skip:    make(map[*goField]bool),
+		fkEdges: make(map[*goField]string),
+	}
+	var order []string
+	for _, n := range files {
+		order = append(order, im.declarations(byName[n])...)
+	}
+	if len(order) == 0 {
+		return nil, nil, fmt.Errorf("no struct types found in %s", dir)
+	}
+	return im.spec(order)
+}
+
+// goImporter converts the struct types of a Go package into a SpecFile.
+type goImporter struct {
+	structs map[string]*ast.StructType
+	// basic maps named types to their underlying predeclared type, e.g. Status to string.
+	basic map[string]string
+	// tables holds the table names returned by the TableName methods.
+	tables map[string]string
+	// embedded holds the structs embedded in other structs. They are no models on their own.
+	embedded map[string]bool
+	models   map[string]*goModel
+	names    map[*SpecSchema]map[string]bool
+	links    []*goLink
+	// skip holds the fields that are imported as edges instead of fields.
+	skip map[*goField]bool
+	// fkEdges maps the foreign keys to the names of their edges.
+	fkEdges map[*goField]string
+	notes
+}
+
+// goModel is a struct type imported as schema.
+type goModel struct {
+	spec   *SpecSchema
+	fields []*goField
+}
+
+// goField is a field of a struct type. Fields of embedded structs are prefixed by the embeddedPrefix.
+type goField struct {
+	name string
+	typ  ast.Expr
+	tag  reflect.StructTag
+	// gorm holds the settings of the gorm tag by their lower-cased name without spaces and underscores, e.g. notnull.
+	gorm map[string]string
+	doc  string
+}
+
+// goLink is a foreign key of the child model referencing the owner model. to and from are the names of the relation
+// fields on either side, empty if there is none.
+type goLink struct {
+	owner, child *goModel
+	fk           *goField
+	to, from     string
+	unique       bool
+}
+
+// declarations collects the type declarations and TableName methods of the file and returns the names of its struct
+// types in declaration order.
+func (im *goImporter) declarations(f *ast.File) []string {
+	var structs []string
+	for _, d := range f.Decls {
+		switch d := d.(type) {
+		case *ast.GenDecl:
+			for _, s := range d.Specs {
+				ts, ok := s.(*ast.TypeSpec)
+				if !ok {
+					continue
+				}
+				switch t := ts.Type.(type) {
+				case *ast.StructType:
+					im.structs[ts.Name.Name] = t
+					structs = append(structs, ts.Name.Name)
+				case *ast.Ident:
+					if predeclared[t.Name] {
+						im.basic[ts.Name.Name] = t.Name
+					}
+				}
+			}
+		case *ast.FuncDecl:
+			if d.Recv == nil || len(d.Recv.List) != 1 || d.Name.Name != "TableName" || d.Body == nil || len(d.Body.List) != 1 {
+				continue
+			}
+			ret, ok := d.Body.List[0].(*ast.ReturnStmt)
+			if !ok || len(ret.Results) != 1 {
+				continue
+			}
+			lit, ok := ret.Results[0].(*ast.BasicLit)
+			if !ok || lit.Kind != token.STRING {
+				continue
+			}
+			recv := d.Recv.List[0].Type
+			if s, ok := recv.(*ast.StarExpr); ok {
+				recv = s.X
+			}
+			if id, ok := recv.(*ast.Ident); ok {
+				im.tables[id.Name], _ = strconv.Unquote(lit.Value)
+			}
+		}
+	}
+	return structs
+}
+
+// spec returns the SpecFile of the struct types with the given names.
+func (im *goImporter) spec(order []string) (*SpecFile, []string, error) {
+	im.embedded = make(map[string]bool)
+	for _, n := range order {
+		for _, f := range im.structs[n].Fields.List {
+			if id, ok := deref(f.Type).(*ast.Ident); ok && (len(f.Names) == 0 || strings.Contains(tagOf(f).Get("gorm"), "embedded")) {
+				im.embedded[id.Name] = true
+			}
+		}
+	}
+	sf := new(SpecFile)
+	var models []*goModel
+	for _, n := range order {
+		if !ast.IsExported(n) || im.embedded[n] {
+			continue
+		}
+		if !nodeNameRgx.MatchString(n) {
+			im.notef("%s: schema names contain only letters, the struct is skipped", n)
+			continue
+		}
+		m := &goModel{spec: &SpecSchema{Name: n, Table: im.tables[n]}}
+		m.fields = im.flatten(n, im.structs[n], "", 0)
+		im.models[n] = m
+		im.names[m.spec] = map[string]bool{"id": true}
+		models = append(models, m)
+		sf.Schemas = append(sf.Schemas, m.spec)
+	}
+	for _, m := range models {
+		im.primaryKey(m)
+	}
+	for _, m := range models {
+		im.relations(m)
+	}
+	for _, m := range models {
+		im.fields(m)
+	}
+	im.edges()
+	for _, m := range models {
+		im.indexes(m)
+	}
+	trimTables(sf)
+	return sf, im.notes, nil
+}
+
+// flatten returns the exported fields of the struct. Embedded structs of the package and gorm.Model are flattened
+// into it. Fields ignored by gorm or the db tag are left out.
+func (im *goImporter) flatten(model string, st *ast.StructType, prefix string, depth int) []*goField {
+	var fs []*goField
+	for _, f := range st.Fields.List {
+		tag := tagOf(f)
+		g := gormSettings(tag.Get("gorm"))
+		if hasKey(g, "-") || tag.Get("db") == "-" {
+			continue
+		}
+		if len(f.Names) == 0 || hasKey(g, "embedded") {
+			switch t := deref(f.Type); {
+			case gotypes.ExprString(t) == "gorm.Model":
+				e, _ := parser.ParseExpr(gormModel)
+				fs = append(fs, im.flatten(model, e.(*ast.StructType), prefix, depth+1)...)
+			case im.structs[identName(t)] != nil && depth < 8:
+				fs = append(fs, im.flatten(model, im.structs[identName(t)], prefix+g["embeddedprefix"], depth+1)...)
+			default:
+				im.notef("%s: the embedded type %s is skipped", model, gotypes.ExprString(f.Type))
+			}
+			continue
+		}
+		for _, n := range f.Names {
+			if !n.IsExported() {
+				continue
+			}
+			gf := &goField{name: n.Name, typ: f.Type, tag: tag, gorm: gormSettings(tag.Get("gorm")), doc: strings.TrimSpace(f.Doc.Text())}
+			// Columns of embedded structs are prefixed by gorm, the field names by ent.
+			if prefix != "" {
+				gf.gorm["column"] = prefix + columnOf(gf)
+				gf.name = pascal(prefix) + n.Name
+			}
+			fs = append(fs, gf)
+		}
+	}
+	return fs
+}
+
+// primaryKey takes the ID type of the schema from the field tagged as primary key or the field named ID.
+func (im *goImporter) primaryKey(m *goModel) {
+	var pks []*goField
+	for _, f := range m.fields {
+		if hasKey(f.gorm, "primarykey") {
+			pks = append(pks, f)
+		}
+	}
+	if len(pks) == 0 {
+		if f := m.field("ID"); f != nil {
+			pks = append(pks, f)
+		}
+	}
+	var cs []string
+	for _, f := range pks {
+		cs = append(cs, columnOf(f))
+	}
+	if len(pks) != 1 {
+		im.notes.primaryKey(m.spec, m.spec.Name, cs, "", "")
+		// The fields share an unnamed unique index.
+		for _, f := range pks {
+			f.gorm["uniqueindex"] = ""
+			f.gorm["pk"] = ""
+		}
+		return
+	}
+	pk := pks[0]
+	im.skip[pk] = true
+	typ, _, _, _ := im.fieldType(pk.typ)
+	im.notes.primaryKey(m.spec, m.spec.Name, cs, typ, gotypes.ExprString(pk.typ))
+}
+
+// relations links the fields of the model holding other models to the foreign keys of the relation.
+func (im *goImporter) relations(m *goModel) {
+	for _, f := range m.fields {
+		if im.skip[f] {
+			continue
+		}
+		slice := false
+		t := deref(f.typ)
+		if a, ok := t.(*ast.ArrayType); ok && a.Len == nil {
+			slice, t = true, deref(a.Elt)
+		}
+		o := im.models[identName(t)]
+		if o == nil {
+			continue
+		}
+		im.skip[f] = true
+		if r := f.gorm["references"]; r != "" && r != "ID" {
+			im.notef("%s.%s: ent edges reference the id, the relation on %s is skipped", m.spec.Name, f.name, f.gorm["references"])
+			continue
+		}
+		switch {
+		case f.gorm["many2many"] != "":
+			im.m2m(m, f, o)
+		case slice:
+			// Has many: the foreign key is a field of the other model.
+			if fk := o.field(orDefault(f.gorm["foreignkey"], m.spec.Name+"ID")); fk != nil {
+				im.link(m, o, fk).to = snake(f.name)
+				continue
+			}
+			im.notef("%s.%s: the foreign key of the has-many relation is missing in %s, the relation is skipped", m.spec.Name, f.name, o.spec.Name)
+		default:
+			// Belongs to: the foreign key is a field of this model. Has one: it is a field of the other model.
+			if fk := m.field(orDefault(f.gorm["foreignkey"], f.name+"ID")); fk != nil {
+				im.link(o, m, fk).from = snake(f.name)
+				continue
+			}
+			if fk := o.field(orDefault(f.gorm["foreignkey"], m.spec.Name+"ID")); fk != nil {
+				l := im.link(m, o, fk)
+				l.to, l.unique = snake(f.name), true
+				continue
+			}
+			im.notef("%s.%s: the foreign key of the relation is missing, the relation is skipped", m.spec.Name, f.name)
+		}
+	}
+}
+
+// link returns the link of the foreign key, creating it if needed.
+func (im *goImporter) link(owner, child *goModel, fk *goField) *goLink {
+	for _, l := range im.links {
+		if l.owner == owner && l.child == child && l.fk == fk {
+			return l
+		}
+	}
+	l := &goLink{owner: owner, child: child, fk: fk}
+	im.skip[fk] = true
+	im.links = append(im.links, l)
+	return l
+}
+
+// m2m adds the M2M edge of the many2many relation field and the back-reference of the field of the other model
+// using the same join table.
+func (im *goImporter) m2m(m *goModel, f *goField, o *goModel) {
+	table := f.gorm["many2many"]
+	cols := []string{snake(m.spec.Name) + "_id", snake(o.spec.Name) + "_id"}
+	if m == o {
+		cols[1] = singular(snake(f.name)) + "_id"
+	}
+	if c := f.gorm["joinforeignkey"]; c != "" {
+		cols[0] = snake(c)
+	}
+	if c := f.gorm["joinreferences"]; c != "" {
+		cols[1] = snake(c)
+	}
+	name := uniqueName(im.names[m.spec], snake(f.name))
+	m.spec.Edges = append(m.spec.Edges, &SpecEdge{Name: name, Type: o.spec.Name, Table: table, Columns: cols})
+	for _, r := range o.fields {
+		t := deref(r.typ)
+		if a, ok := t.(*ast.ArrayType); ok && r != f && r.gorm["many2many"] == table && identName(deref(a.Elt)) == m.spec.Name {
+			im.skip[r] = true
+			o.spec.Edges = append(o.spec.Edges, &SpecEdge{Name: uniqueName(im.names[o.spec], snake(r.name)), Type: m.spec.Name, Ref: name})
+			return
+		}
+	}
+}
+
+// fields adds the fields of the model that are neither primary keys nor relations to its schema.
+func (im *goImporter) fields(m *goModel) {
+	for _, f := range m.fields {
+		if im.skip[f] {
+			continue
+		}
+		if sf := im.field(m, f); sf != nil {
+			m.spec.Fields = append(m.spec.Fields, sf)
+		}
+	}
+}
+
+// field returns the field of the struct field. nil if its type cannot be mapped.
+func (im *goImporter) field(m *goModel, f *goField) *SpecField {
+	typ, goType, nillable, ok := im.fieldType(f.typ)
+	if !ok {
+		im.notef("%s.%s: the type %s cannot be mapped to an ent field, the field is skipped", m.spec.Name, f.name, gotypes.ExprString(f.typ))
+		return nil
+	}
+	if u, ok := im.basic[identName(deref(f.typ))]; ok {
+		im.notef("%s.%s: the field is stored as %s, set the Go type %s to keep it", m.spec.Name, f.name, u, identName(deref(f.typ)))
+	}
+	g := f.gorm
+	sf := &SpecField{
+		Name:     uniqueName(im.names[m.spec], snake(f.name)),
+		Type:     typ,
+		GoType:   goType,
+		Optional: nillable && !hasKey(g, "notnull"),
+		Nillable: nillable && hasNillable(typ),
+		Comment:  orDefault(g["comment"], f.doc),
+	}
+	if hasKey(g, "unique") {
+		setUnique(m.spec, sf)
+	}
+	if c := columnOf(f); c != sf.Name {
+		sf.StorageKey = c
+	}
+	if j := f.tag.Get("json"); j != "" && j != sf.Name+",omitempty" {
+		sf.StructTag = fmt.Sprintf("json:%q", j)
+	}
+	if t := strings.ToLower(g["type"]); t != "" {
+		if typ == "string" && strings.HasSuffix(t, "text") {
+			sf.Type = "text"
+		} else {
+			im.notef("%s.%s: the column type %s is not kept, set it as schema type", m.spec.Name, f.name, g["type"])
+		}
+	}
+	if n, err := strconv.Atoi(g["size"]); err == nil && (sf.Type == "string" || sf.Type == "text") {
+		sf.MaxLen = n
+	}
+	if v, ok := g["default"]; ok {
+		c := &column{def: v}
+		if len(v) > 1 && v[0] == '\'' && v[len(v)-1] == '\'' {
+			c.def, c.defString = v[1:len(v)-1], true
+		}
+		switch d, ok := defaultOf(sf.Type, c); {
+		case !ok:
+			im.notef("%s.%s: the default %s cannot be expressed by ent", m.spec.Name, f.name, v)
+		case d != "":
+			sf.Default = d
+		}
+	}
+	// gorm tracks the creation and update time of these fields.
+	switch {
+	case sf.Type != "time":
+	case hasKey(g, "autocreatetime") || f.name == "CreatedAt":
+		sf.Default, sf.Immutable = "time.Now", true
+	case hasKey(g, "autoupdatetime") || f.name == "UpdatedAt":
+		sf.Default, sf.UpdateDefault = "time.Now", true
+	}
+	if strings.HasPrefix(g["<-"], "create") {
+		sf.Immutable = true
+	}
+	return sf
+}
+
+// fieldType returns the wizard type and the Go type of json fields of the Go type. nillable reports whether the
+// type can hold null, e.g. pointers and sql.NullString.
+func (im *goImporter) fieldType(e ast.Expr) (typ, goType string, nillable, ok bool) {
+	switch t := e.(type) {
+	case *ast.StarExpr:
+		typ, goType, _, ok = im.fieldType(t.X)
+		return typ, goType, true, ok
+	case *ast.Ident:
+		switch n := t.Name; n {
+		case "float64":
+			return "float", "", false, true
+		case "byte":
+			return "uint8", "", false, true
+		case "rune":
+			return "int32", "", false, true
+		case "string", "bool", "int", "int8", "int16", "int32", "int64", "uint", "uint8", "uint16", "uint32", "uint64", "float32":
+			return n, "", false, true
+		}
+		if u, ok := im.basic[t.Name]; ok {
+			return im.fieldType(ast.NewIdent(u))
+		}
+	case *ast.SelectorExpr:
+		switch gotypes.ExprString(t) {
+		case "time.Time", "datatypes.Date":
+			return "time", "", false, true
+		case "uuid.UUID":
+			return "uuid", "", false, true
+		case "gorm.DeletedAt", "sql.NullTime", "mysql.NullTime", "pq.NullTime":
+			return "time", "", true, true
+		case "sql.NullString":
+			return "string", "", true, true
+		case "sql.NullInt64":
+			return "int64", "", true, true
+		case "sql.NullInt32":
+			return "int32", "", true, true
+		case "sql.NullInt16":
+			return "int16", "", true, true
+		case "sql.NullByte":
+			return "uint8", "", true, true
+		case "sql.NullFloat64":
+			return "float", "", true, true
+		case "sql.NullBool":
+			return "bool", "", true, true
+		case "json.RawMessage", "datatypes.JSON":
+			return "json", "json.RawMessage", false, true
+		}
+	case *ast.ArrayType:
+		if s := gotypes.ExprString(t.Elt); t.Len == nil && (s == "byte" || s == "uint8") {
+			return "[]byte", "", false, true
+		}
+		if t.Len == nil && predeclaredOnly(t) {
+			return "json", gotypes.ExprString(t), false, true
+		}
+	case *ast.MapType:
+		if predeclaredOnly(t) {
+			return "json", gotypes.ExprString(t), false, true
+		}
+	}
+	return "", "", false, false
+}
+
+// edges adds the edges of the links to the schemas. The child holding the foreign key gets a back-reference if it
+// has a field holding the owner.
+func (im *goImporter) edges() {
+	for _, l := range im.links {
+		os, cs := l.owner.spec, l.child.spec
+		to := l.to
+		if to == "" {
+			to = pluralName(snake(cs.Name))
+			if l.from != "" && (os == cs || im.linked(l.owner, l.child) > 1) {
+				to = l.from + "_" + to
+			}
+		}
+		to = uniqueName(im.names[os], to)
+		os.Edges = append(os.Edges, &SpecEdge{Name: to, Type: cs.Name, Unique: l.unique, Columns: []string{columnOf(l.fk)}})
+		if l.from == "" {
+			continue
+		}
+		_, _, nillable, _ := im.fieldType(l.fk.typ)
+		from := uniqueName(im.names[cs], l.from)
+		im.fkEdges[l.fk] = from
+		cs.Edges = append(cs.Edges, &SpecEdge{Name: from, Type: os.Name, Ref: to, Unique: true, Required: !nillable || hasKey(l.fk.gorm, "notnull")})
+	}
+}
+
+// linked returns the number of links between the owner and the child.
+func (im *goImporter) linked(owner, child *goModel) int {
+	n := 0
+	for _, l := range im.links {
+		if l.owner == owner && l.child == child {
+			n++
+		}
+	}
+	return n
+}
+
+// indexes adds the indexes declared by the index and uniqueIndex settings of the gorm tags. Indexes of the same name
+// span multiple fields. Foreign keys are indexed by their edges.
+func (im *goImporter) indexes(m *goModel) {
+	var (
+		order   []string
+		byKey   = make(map[string]*SpecIndex)
+		dropped = make(map[string]bool)
+	)
+	for _, f := range m.fields {
+		for _, k := range []string{"index", "uniqueindex"} {
+			v, ok := f.gorm[k]
+			if !ok {
+				continue
+			}
+			name := strings.Split(v, ",")[0]
+			key := k + ":" + name
+			switch {
+			case hasKey(f.gorm, "pk"):
+				key = "pk"
+			case name == "":
+				key += ":" + f.name
+			}
+			si, ok := byKey[key]
+			if !ok {
+				si = &SpecIndex{Unique: k == "uniqueindex", StorageKey: name}
+				byKey[key] = si
+				order = append(order, key)
+			}
+			switch n := specFieldOf(m.spec, columnOf(f)); {
+			case im.fkEdges[f] != "":
+				si.Edges = append(si.Edges, im.fkEdges[f])
+			case n != "" && !im.skip[f]:
+				si.Fields = append(si.Fields, n)
+			default:
+				// The primary key, a foreign key without edge on this side or a skipped field.
+				dropped[key] = true
+			}
+		}
+	}
+	for _, key := range order {
+		si := byKey[key]
+		// Foreign keys are indexed anyway, unique ones are O2O edges.
+		if !dropped[key] && len(si.Fields) > 0 {
+			addIndex(m.spec, si)
+		}
+	}
+}
+
+// field returns the field of the model with the given Go name. nil if there is none.
+func (m *goModel) field(name string) *goField {
+	for _, f := range m.fields {
+		if f.name == name {
+			return f
+		}
+	}
+	return nil
+}
+
+// specFieldOf returns the name of the field of the schema stored in the column. Empty if there is none.
+func specFieldOf(s *SpecSchema, col string) string {
+	for _, f := range s.Fields {
+		if f.Name == col && f.StorageKey == "" || f.StorageKey == col {
+			return f.Name
+		}
+	}
+	return ""
+}
+
+// specField returns the field of the schema with the given name. nil if there is none.
+func specField(s *SpecSchema, name string) *SpecField {
+	for _, f := range s.Fields {
+		if f.Name == name {
+			return f
+		}
+	}
+	return nil
+}
+
+// predeclared holds the predeclared types of Go.
+var predeclared = map[string]bool{
+	"string": true, "bool": true, "byte": true, "rune": true, "float32": true, "float64": true,
+	"int": true, "int8": true, "int16": true, "int32": true, "int64": true,
+	"uint": true, "uint8": true, "uint16": true, "uint32": true, "uint64": true,
+}
+
+// predeclaredOnly reports whether the type expression only references predeclared types, e.g. []string or
+// map[string]interface{}.
+func predeclaredOnly(e ast.Expr) bool {
+	ok := true
+	ast.Inspect(e, func(n ast.Node) bool {
+		switch n := n.(type) {
+		case *ast.SelectorExpr, *ast.StarExpr, *ast.FuncType, *ast.ChanType, *ast.StructType:
+			ok = false
+		case *ast.InterfaceType:
+			ok = ok && len(n.Methods.List) == 0
+		case *ast.Ident:
+			ok = ok && predeclared[n.Name]
+		}
+		return ok
+	})
+	return ok
+}
+
+// columnOf returns the name of the column of the field given by the gorm or db tag. Defaults to the snake_case name of
+// the field like gorm does.
+func columnOf(f *goField) string {
+	if c := f.gorm["column"]; c != "" {
+		return c
+	}
+	if c := strings.Split(f.tag.Get("db"), ",")[0]; c != "" {
+		return c
+	}
+	return snake(f.name)
+}
+
+// gormSettings parses the gorm tag, e.g. "column:name;not null;index:idx_name". The names of the settings are lower
+// cased without spaces and underscores, settings without value are mapped to an empty string.
+func gormSettings(tag string) map[string]string {
+	g := make(map[string]string)
+	for _, s := range strings.Split(tag, ";") {
+		if s = strings.TrimSpace(s); s == "" {
+			continue
+		}
+		kv := strings.SplitN(s, ":", 2)
+		k := strings.NewReplacer(" ", "", "_", "").Replace(strings.ToLower(kv[0]))
+		if k == "" {
+			// A single "-" is the key itself.
+			k = kv[0]
+		}
+		if len(kv) == 2 {
+			g[k] = strings.TrimSpace(kv[1])
+		} else {
+			g[k] = ""
+		}
+	}
+	return g
+}
+
+// tagOf returns the struct tag of the field.
+func tagOf(f *ast.Field) reflect.StructTag {
+	if f.Tag == nil {
+		return ""
+	}
+	t, _ := strconv.Unquote(f.Tag.Value)
+	return reflect.StructTag(t)
+}
+
+// hasKey reports whether the gorm settings contain the given one.
+func hasKey(g map[string]string, k string) bool {
+	_, ok := g[k]
+	return ok
+}
+
+// deref returns the type e points to. e itself if it is no pointer.
+func deref(e ast.Expr) ast.Expr {
+	if s, ok := e.(*ast.StarExpr); ok {
+		return s.X
+	}
+	return e
+}
+
+// identName returns the name of the identifier. Empty if e is no identifier.
+func identName(e ast.Expr) string {
+	if id, ok := e.(*ast.Ident); ok {
+		return id.Name
+	}
+	return ""
+}
+
+// orDefault returns s or def if s is empty.
+func orDefault(s, def string) string {
+	if s == "" {
+		return def
+	}
+	return s
+}
@@ -0,0 +1,101 @@
+package wapiti
+
+import (
+	"entgo.io/ent/entc/load"
+	"github.com/stretchr/testify/require"
+	"testing"
+)
+
+func TestImportGo(t *testing.T) {
+	sf, notes, err := ImportGo("testdata/gorm")
+	require.NoError(t, err)
+	require.Equal(t, []string{"User.Role: the field is stored as string, set the Go type Role to keep it"}, notes)
+	require.Len(t, sf.Schemas, 5)
+	u, p, pet, l, s := sf.Schemas[0], sf.Schemas[1], sf.Schemas[2], sf.Schemas[3], sf.Schemas[4]
+
+	// gorm.Model is flattened into the struct.
+	require.Equal(t, "User", u.Name)
+	require.Empty(t, u.ID)
+	require.Equal(t, []*SpecField{
+		{Name: "created_at", Type: "time", Default: "time.Now", Immutable: true},
+		{Name: "updated_at", Type: "time", Default: "time.Now", UpdateDefault: true},
+		{Name: "deleted_at", Type: "time", Optional: true, Nillable: true},
+		{Name: "name", Type: "string", MaxLen: 64, StructTag: `json:"name"`},
+		{Name: "email", Type: "string", Unique: true, StructTag: `json:"email"`},
+		{Name: "nickname", Type: "string", Optional: true, Nillable: true, StructTag: `json:"nick,omitempty"`},
+		{Name: "role", Type: "string", Default: "member"},
+		{Name: "bio", Type: "text"},
+		{Name: "active", Type: "bool", Default: "true"},
+		{Name: "settings", Type: "json", GoType: "map[string]interface{}"},
+	}, u.Fields)
+	require.Equal(t, []*SpecEdge{
+		{Name: "languages", Type: "Language", Table: "user_languages", Columns: []string{"user_id", "language_id"}},
+		{Name: "friends", Type: "User", Table: "user_friends", Columns: []string{"user_id", "friend_id"}},
+		{Name: "pets", Type: "Pet", Columns: []string{"owner_id"}},
+		{Name: "profile", Type: "Profile", Unique: true, Columns: []string{"user_id"}},
+		{Name: "sessions", Type: "Session", Columns: []string{"user_id"}},
+	}, u.Edges)
+	require.Equal(t, []*SpecIndex{{Fields: []string{"deleted_at"}}}, u.Indexes)
+
+	// The foreign key of a has-one relation without back-reference is stored by the edge.
+	require.Equal(t, &SpecSchema{Name: "Profile", Fields: []*SpecField{{Name: "bio", Type: "string", Optional: true, Nillable: true}}}, p)
+
+	require.Equal(t, []*SpecField{
+		{Name: "name", Type: "string"},
+		{Name: "addr_street", Type: "string"},
+		{Name: "addr_city", Type: "string", StorageKey: "addr_town"},
+		{Name: "born", Type: "time", Default: "time.Now", Immutable: true},
+		{Name: "tags", Type: "json", GoType: "[]string"},
+		{Name: "extra", Type: "json", GoType: "[]string", Optional: true},
+		{Name: "data", Type: "[]byte"},
+	}, pet.Fields)
+	require.Equal(t, []*SpecEdge{{Name: "owner", Type: "User", Ref: "pets", Unique: true, Required: true}}, pet.Edges)
+	require.Equal(t, []*SpecIndex{{Fields: []string{"name"}, Edges: []string{"owner"}, StorageKey: "idx_pet_name_owner"}}, pet.Indexes)
+
+	require.Equal(t, &SpecSchema{
+		Name:         "Language",
+		ID:           "string",
+		IDStorageKey: "code",
+		Table:        "lang",
+		Edges:        []*SpecEdge{{Name: "users", Type: "User", Ref: "languages"}},
+	}, l)
+
+	// Embedded structs of the package are flattened as well.
+	require.Equal(t, "uuid", s.ID)
+	require.Equal(t, []*SpecField{
+		{Name: "created_at", Type: "time", Default: "time.Now", Immutable: true},
+		{Name: "token", Type: "string", StorageKey: "token_value"},
+		{Name: "expiry", Type: "time", Optional: true, Nillable: true},
+	}, s.Fields)
+	require.Equal(t, []*SpecEdge{{Name: "user", Type: "User", Ref: "sessions", Unique: true}}, s.Edges)
+
+	// The fields can be written.
+	w := &Wapiti{spec: &load.SchemaSpec{}}
+	for _, ss := range sf.Schemas {
+		for _, sf := range ss.Fields {
+			f, err := sf.field(w, &load.Schema{Name: ss.Name})
+			require.NoError(t, err)
+			_, err = f.code()
+			require.NoError(t, err, sf.Name)
+		}
+	}
+	f, err := u.Fields[3].field(w, &load.Schema{Name: "User"})
+	require.NoError(t, err)
+	c, err := f.code()
+	require.NoError(t, err)
+	require.Equal(t, "field.String(\"name\").MaxLen(64).StructTag(`json:\"name\"`)", c)
+
+	_, _, err = ImportGo(t.TempDir())
+	require.Error(t, err)
+}
+
+func TestGormSettings(t *testing.T) {
+	require.Equal(t, map[string]string{
+		"column":      "name",
+		"notnull":     "",
+		"uniqueindex": "idx_name,sort:desc",
+		"default":     "'a:b'",
+	}, gormSettings("column:name; NOT NULL;unique_index:idx_name,sort:desc;default:'a:b'"))
+	require.Equal(t, map[string]string{"-": ""}, gormSettings("-"))
+	require.Equal(t, map[string]string{"-": "all"}, gormSettings("-:all"))
+}
@@ -0,0 +1,209 @@
+package wapiti
+
+import (
+	"fmt"
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"golang.org/x/tools/go/packages"
+	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// qualifiedRgx matches qualified identifiers like models.User or github.com/org/app/models.User in a type expression.
+var qualifiedRgx = regexp.MustCompile(`((?:[\w.-]+/)*[A-Za-z_][\w-]*)\.([A-Za-z_]\w*)`)
+
+// versionRgx matches the major version suffix of an import path.
+var versionRgx = regexp.MustCompile(`^v[0-9]+$`)
+
+// basicTypes are the predeclared types that cannot be used as custom Go type.
+var basicTypes = map[string]bool{
+	"bool": true, "string": true, "byte": true, "rune": true, "error": true,
+	"int": true, "int8": true, "int16": true, "int32": true, "int64": true,
+	"uint": true, "uint8": true, "uint16": true, "uint32": true, "uint64": true, "uintptr": true,
+	"float32": true, "float64": true, "complex64": true, "complex128": true,
+}
+
+// ResolveGoType parses the Go type expression t, e.g. `[]string` or `*models.User`, and returns it together with the
+// import paths of the packages it references, keyed by package name. Packages given by name are searched in the
+// module of the schema and the standard library. A package can also be given by its import path, e.g.
+// `*github.com/org/app/models.User`. The returned expression only contains package names.
+func (w *Wapiti) ResolveGoType(t string) (string, map[string]string, error) {
+	t = strings.TrimSpace(t)
+	pkgs := make(map[string]string)
+	var err error
+	t = qualifiedRgx.ReplaceAllStringFunc(t, func(m string) string {
+		sm := qualifiedRgx.FindStringSubmatch(m)
+		qual, name := sm[1], sm[2]
+		var p string
+		switch {
+		case strings.Contains(qual, "/"):
+			p, qual = qual, packageName(qual)
+		case imports[qual] != "":
+			p = imports[qual]
+		default:
+			var perr error
+			if p, perr = w.findPackage(qual, name); perr != nil && err == nil {
+				err = perr
+			}
+		}
+		if o, ok := pkgs[qual]; ok && o != p && err == nil {
+			err = fmt.Errorf("package name %s is used for %s and %s", qual, o, p)
+		}
+		pkgs[qual] = p
+		return qual + "." + name
+	})
+	if err != nil {
+		return "", nil, err
+	}
+	if _, err := parser.ParseExpr(t); err != nil {
+		return "", nil, fmt.Errorf("invalid Go type %q", t)
+	}
+	return t, pkgs, nil
+}
+
+// goTypeName returns the Go type expression t with import paths replaced by package names, like ResolveGoType does,
+// without resolving the packages.
+func goTypeName(t string) string {
+	return qualifiedRgx.ReplaceAllStringFunc(strings.TrimSpace(t), func(m string) string {
+		sm := qualifiedRgx.FindStringSubmatch(m)
+		if !strings.Contains(sm[1], "/") {
+			return m
+		}
+		return packageName(sm[1]) + "." + sm[2]
+	})
+}
+
+// packageName returns the name of the package with the given import path, assuming it matches the last element of
+// the path without a major version suffix.
+func packageName(p string) string {
+	n := path.Base(p)
+	if versionRgx.MatchString(n) && path.Dir(p) != "." {
+		n = path.Base(path.Dir(p))
+	}
+	return strings.ReplaceAll(n, "-", "_")
+}
+
+// findPackage returns the import path of the package with the given name declaring the type typ. The module of the
+// schema is searched first, then the standard library.
+func (w *Wapiti) findPackage(name, typ string) (string, error) {
+	for _, pattern := range []string{"./...", "std"} {
+		cfg := &packages.Config{Mode: packages.NeedName | packages.NeedFiles, Dir: moduleRoot(w.cfg.SchemaPath)}
+		ps, err := packages.Load(cfg, pattern)
+		if err != nil {
+			return "", fmt.Errorf("loading packages: %w", err)
+		}
+		var found []string
+		for _, p := range ps {
+			if p.Name == name && declaresType(p.GoFiles, typ) {
+				found = append(found, p.PkgPath)
+			}
+		}
+		sort.Strings(found)
+		switch len(found) {
+		case 0:
+			continue
+		case 1:
+			return found[0], nil
+		default:
+			return "", fmt.Errorf("%s.%s is declared in %s, use the import path to choose one", name, typ, strings.Join(found, ", "))
+		}
+	}
+	return "", fmt.Errorf("no package %s declaring %s found", name, typ)
+}
+
+// moduleRoot returns the directory of the go.mod file enclosing dir. dir itself if there is none.
+func moduleRoot(dir string) string {
+	dir, err := filepath.Abs(dir)
+	if err != nil {
+		return dir
+	}
+	for d := dir; ; d = filepath.Dir(d) {
+		if _, err := os.Stat(filepath.Join(d, "go.mod")); err == nil {
+			return d
+		}
+		if filepath.Dir(d) == d {
+			return dir
+		}
+	}
+}
+
+// declaresType reports whether one of the files declares the type with the given name.
+func declaresType(files []string, typ string) bool {
+	fset := token.NewFileSet()
+	for _, name := range files {
+		f, err := parser.ParseFile(fset, name, nil, 0)
+		if err != nil {
+			continue
+		}
+		for _, d := range f.Decls {
+			if g, ok := d.(*ast.GenDecl); ok && g.Tok == token.TYPE {
+				for _, s := range g.Specs {
+					if s.(*ast.TypeSpec).Name.Name == typ {
+						return true
+					}
+				}
+			}
+		}
+	}
+	return false
+}
+
+// typeValue returns an expression of the Go type t as passed to the builder of a field of the given type, e.g.
+// `models.Level(0)` for an int field or `&models.Point{}` for an other field.
+func typeValue(typ, t string) (string, error) {
+	if t == "" {
+		return "", fmt.Errorf("%s fields need a Go type", typ)
+	}
+	switch typ {
+	case "json":
+		if strings.HasPrefix(t, "*") {
+			return "&" + t[1:] + "{}", nil
+		}
+		if basicTypes[t] {
+			return "", fmt.Errorf("%s cannot be used as Go type of a json field, use a %s field instead", t, t)
+		}
+		return t + "{}", nil
+	case "other":
+		return "&" + strings.TrimPrefix(t, "*") + "{}", nil
+	}
+	if strings.HasPrefix(t, "*") {
+		return "", fmt.Errorf("the Go type of %s fields cannot be a pointer", typ)
+	}
+	switch typ {
+	case "int", "int8", "int16", "int32", "int64", "uint", "uint8", "uint16", "uint32", "uint64", "float", "float32":
+		return t + "(0)", nil
+	case "string", "text", "enum":
+		return t + `("")`, nil
+	case "bool":
+		return t + "(false)", nil
+	case "[]byte":
+		return t + "(nil)", nil
+	case "time", "uuid":
+		return t + "{}", nil
+	}
+	return "", fmt.Errorf("%s fields cannot have a custom Go type", typ)
+}
+
+// typeOf returns the Go type of an expression created by typeValue.
+func (s *source) typeOf(e ast.Expr) string {
+	switch e := e.(type) {
+	case *ast.UnaryExpr:
+		if lit, ok := e.X.(*ast.CompositeLit); ok && e.Op == token.AND && lit.Type != nil {
+			return "*" + s.text(lit.Type)
+		}
+	case *ast.CompositeLit:
+		if e.Type != nil {
+			return s.text(e.Type)
+		}
+	case *ast.CallExpr:
+		if len(e.Args) == 1 {
+			return s.text(e.Fun)
+		}
+	}
+	return ""
+}
@@ -0,0 +1,74 @@
+package wapiti
+
+import (
+	"github.com/masseelch/wapiti/wapiti/config"
+	"github.com/stretchr/testify/require"
+	"go/ast"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestResolveGoType(t *testing.T) {
+	dir := t.TempDir()
+	require.NoError(t, ioutil.WriteFile(filepath.Join(dir, "go.mod"), []byte("module example.com/app\n\ngo 1.16\n"), 0644))
+	require.NoError(t, os.MkdirAll(filepath.Join(dir, "ent", "schema"), 0755))
+	require.NoError(t, os.MkdirAll(filepath.Join(dir, "models"), 0755))
+	require.NoError(t, ioutil.WriteFile(filepath.Join(dir, "models", "models.go"), []byte("package models\n\ntype Point struct{}\n"), 0644))
+	w := &Wapiti{cfg: &config.Config{SchemaPath: filepath.Join(dir, "ent", "schema")}}
+
+	for _, tt := range []struct {
+		in, want string
+		pkgs     map[string]string
+	}{
+		{"[]string", "[]string", map[string]string{}},
+		{"*models.Point", "*models.Point", map[string]string{"models": "example.com/app/models"}},
+		{"map[string]models.Point", "map[string]models.Point", map[string]string{"models": "example.com/app/models"}},
+		{"*github.com/org/pkg/v2.Data", "*pkg.Data", map[string]string{"pkg": "github.com/org/pkg/v2"}},
+		{"uuid.UUID", "uuid.UUID", map[string]string{"uuid": "github.com/google/uuid"}},
+		{"sql.NullString", "sql.NullString", map[string]string{"sql": "database/sql"}},
+	} {
+		typ, pkgs, err := w.ResolveGoType(tt.in)
+		require.NoError(t, err, tt.in)
+		require.Equal(t, tt.want, typ)
+		require.Equal(t, tt.pkgs, pkgs)
+	}
+	for _, in := range []string{"models.Unknown", "unknown.Point", "[]models.Point{"} {
+		_, _, err := w.ResolveGoType(in)
+		require.Error(t, err, in)
+	}
+}
+
+func TestTypeValue(t *testing.T) {
+	for _, tt := range []struct {
+		typ, t, want string
+	}{
+		{"json", "[]string", "[]string{}"},
+		{"json", "*models.Data", "&models.Data{}"},
+		{"other", "models.Point", "&models.Point{}"},
+		{"other", "*models.Point", "&models.Point{}"},
+		{"int", "models.Level", "models.Level(0)"},
+		{"string", "models.Name", `models.Name("")`},
+		{"uuid", "pgtype.UUID", "pgtype.UUID{}"},
+	} {
+		v, err := typeValue(tt.typ, tt.t)
+		require.NoError(t, err)
+		require.Equal(t, tt.want, v)
+
+		// The type can be read back from the value.
+		s, err := parseSource("v.go", []byte("package v\n\nvar v = "+v+"\n"))
+		require.NoError(t, err)
+		want := tt.t
+		if tt.typ == "other" && want[0] != '*' {
+			want = "*" + want
+		}
+		require.Equal(t, want, s.typeOf(s.file.Scope.Lookup("v").Decl.(*ast.ValueSpec).Values[0]))
+	}
+	for _, tt := range []struct{ typ, t string }{
+		{"json", ""}, {"json", "int"}, {"int", "*models.Level"}, {"bool", ""},
+	} {
+		_, err := typeValue(tt.typ, tt.t)
+		require.Error(t, err, tt)
+	}
+}
@@ -0,0 +1,130 @@
+package wapiti
+
+import (
+	"entgo.io/ent/entc/load"
+	"fmt"
+	"github.com/c-bata/go-prompt"
+	"github.com/logrusorgru/aurora/v3"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"text/template"
+)
+
+var hooksTpl = template.Must(template.New("hooks").Parse(`
+// Hooks of the {{ . }}.
+func ({{ . }}) Hooks() []ent.Hook {
+	return nil
+}
+`))
+
+var hookTpl = template.Must(template.New("hook").Parse(`hook.On(
+	func(next ent.Mutator) ent.Mutator {
+		return hook.{{ .Schema }}Func(func(ctx context.Context, m *gen.{{ .Schema }}Mutation) (ent.Value, error) {
+			// Put the logic to run before the mutation here. Return an error to abort it.
+			v, err := next.Mutate(ctx, m)
+			// Put the logic to run after the mutation here.
+			return v, err
+		})
+	},
+	{{ .Ops }},
+)`))
+
+// hookOps maps the operations a hook can run on to the ent constants.
+var hookOps = map[string]string{
+	"Create":    "ent.OpCreate",
+	"Update":    "ent.OpUpdate",
+	"UpdateOne": "ent.OpUpdateOne",
+	"Delete":    "ent.OpDelete",
+	"DeleteOne": "ent.OpDeleteOne",
+}
+
+// Hook is a hook to add to a schema.
+type Hook struct {
+	Schema *load.Schema
+	// Ops are the operations the hook runs on, e.g. Create and UpdateOne.
+	Ops []string
+}
+
+// code returns the skeleton of the hook.
+func (h *Hook) code() (string, error) {
+	if len(h.Ops) == 0 {
+		return "", fmt.Errorf("hooks need at least one operation")
+	}
+	ops := make([]string, len(h.Ops))
+	for i, o := range h.Ops {
+		c, ok := hookOps[o]
+		if !ok {
+			return "", fmt.Errorf("unknown operation %q: choose from Create, Update, UpdateOne, Delete and DeleteOne", o)
+		}
+		ops[i] = c
+	}
+	b := new(strings.Builder)
+	if err := hookTpl.Execute(b, map[string]string{"Schema": h.Schema.Name, "Ops": strings.Join(ops, "|")}); err != nil {
+		return "", fmt.Errorf("executing template %s: %w", hookTpl.Name(), err)
+	}
+	return b.String(), nil
+}
+
+// AddHook adds a skeleton of the hook to the Hooks method of the schema. The generated code must exist, since the
+// hook references the generated hook package. Calls Reload afterwards.
+func (w *Wapiti) AddHook(h *Hook) error {
+	code, err := h.code()
+	if err != nil {
+		return err
+	}
+	gen, err := w.generated("hook")
+	if err != nil {
+		return err
+	}
+	return w.appendToMethod(h.Schema, "Hooks", hooksTpl, "[]ent.Hook", code, map[string]string{
+		"context": "context",
+		"gen":     gen,
+		"hook":    path.Join(gen, "hook"),
+	})
+}
+
+// generated returns the import path of the package generated by ent. An error is returned if the given sub-package
+// of it has not been generated yet.
+func (w *Wapiti) generated(pkg string) (string, error) {
+	dir, err := filepath.Abs(w.cfg.SchemaPath)
+	if err != nil {
+		return "", err
+	}
+	if _, err := os.Stat(filepath.Join(filepath.Dir(dir), pkg)); err != nil {
+		return "", fmt.Errorf("package %s has not been generated yet, run go generate first", path.Join(path.Dir(w.spec.PkgPath), pkg))
+	}
+	return path.Dir(w.spec.PkgPath), nil
+}
+
+// hookOpSuggestions are the operations a hook can run on.
+var hookOpSuggestions = []prompt.Suggest{
+	{Text: "Create", Description: "Run on creation of an entity"},
+	{Text: "Update", Description: "Run on updates of many entities"},
+	{Text: "UpdateOne", Description: "Run on updates of a single entity"},
+	{Text: "Delete", Description: "Run on deletion of many entities"},
+	{Text: "DeleteOne", Description: "Run on deletion of a single entity"},
+}
+
+// NewHook asks the user on what operations a hook to add to the given node runs. Returns nil, nil if the user wants to
+// stop adding hooks.
+func (w *Wapiti) NewHook(s *load.Schema) (*Hook, error) {
+	// Hooks can only be added once the code has been generated.
+	if _, err := w.generated("hook"); err != nil {
+		fmt.Println(aurora.Yellow("\nskipping hooks: " + err.Error()))
+		return nil, nil
+	}
+	ops := ask(append(hookOpSuggestions, shortcuts...), "Operations a hook runs on, separated by space (press <return> to stop adding hooks):")
+	if ops == "" {
+		return nil, nil
+	}
+	if w.shortcut(ops) {
+		return nil, errShortcut
+	}
+	h := &Hook{Schema: s, Ops: splitList(ops)}
+	if err := w.AddHook(h); err != nil {
+		return nil, err
+	}
+	return h, nil
+}
@@ -0,0 +1,72 @@
+package wapiti
+
+import (
+	"entgo.io/ent/entc/load"
+	"github.com/masseelch/wapiti/wapiti/config"
+	"github.com/stretchr/testify/require"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestHookCode(t *testing.T) {
+	h := &Hook{Schema: &load.Schema{Name: "Pet"}, Ops: []string{"Create", "UpdateOne"}}
+	c, err := h.code()
+	require.NoError(t, err)
+	s, err := parseSource("pet.go", []byte("package schema\n\nimport \"entgo.io/ent\"\n\ntype Pet struct {\n\tent.Schema\n}\n"))
+	require.NoError(t, err)
+	require.NoError(t, s.appendToMethod("Pet", "Hooks", hooksTpl, "[]ent.Hook", c))
+	s.imports = map[string]string{"context": "context", "gen": "example.com/app/ent", "hook": "example.com/app/ent/hook"}
+	b, err := s.output()
+	require.NoError(t, err)
+	require.Equal(t, `package schema
+
+import (
+	"context"
+	"entgo.io/ent"
+	gen "example.com/app/ent"
+	"example.com/app/ent/hook"
+)
+
+type Pet struct {
+	ent.Schema
+}
+
+// Hooks of the Pet.
+func (Pet) Hooks() []ent.Hook {
+	return []ent.Hook{
+		hook.On(
+			func(next ent.Mutator) ent.Mutator {
+				return hook.PetFunc(func(ctx context.Context, m *gen.PetMutation) (ent.Value, error) {
+					// Put the logic to run before the mutation here. Return an error to abort it.
+					v, err := next.Mutate(ctx, m)
+					// Put the logic to run after the mutation here.
+					return v, err
+				})
+			},
+			ent.OpCreate|ent.OpUpdateOne,
+		),
+	}
+}
+`, string(b))
+
+	for _, ops := range [][]string{nil, {"Insert"}} {
+		_, err := (&Hook{Schema: h.Schema, Ops: ops}).code()
+		require.Error(t, err)
+	}
+}
+
+func TestGenerated(t *testing.T) {
+	dir := t.TempDir()
+	require.NoError(t, os.MkdirAll(filepath.Join(dir, "ent", "schema"), 0755))
+	w := &Wapiti{
+		cfg:  &config.Config{SchemaPath: filepath.Join(dir, "ent", "schema")},
+		spec: &load.SchemaSpec{PkgPath: "example.com/app/ent/schema"},
+	}
+	_, err := w.generated("hook")
+	require.EqualError(t, err, "package example.com/app/ent/hook has not been generated yet, run go generate first")
+	require.NoError(t, os.Mkdir(filepath.Join(dir, "ent", "hook"), 0755))
+	p, err := w.generated("hook")
+	require.NoError(t, err)
+	require.Equal(t, "example.com/app/ent", p)
+}
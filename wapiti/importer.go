@@ -0,0 +1,103 @@
+package wapiti
+
+import (
+	"fmt"
+	"strings"
+)
+
+// notes collects the notes of an importer on the parts of the source that cannot be expressed by ent.
+type notes []string
+
+// notef adds a note on something that cannot be imported.
+func (ns *notes) notef(format string, args ...interface{}) {
+	*ns = append(*ns, fmt.Sprintf(format, args...))
+}
+
+// primaryKey sets the ID of the schema to the primary key made of the given columns. typ is the type of the key, raw
+// its type as declared in the source. where prefixes the notes. ent needs a single primary key column: an id column
+// is added if there is none, composite primary keys are noted and must be turned into a unique index by the caller.
+// Primary keys not named id become the id field stored in their column.
+func (ns *notes) primaryKey(s *SpecSchema, where string, cols []string, typ, raw string) {
+	switch {
+	case len(cols) == 0:
+		ns.notef("%s: the table has no primary key, ent adds an id column", where)
+		return
+	case len(cols) > 1:
+		ns.notef("%s: ent needs a single primary key column, the composite primary key (%s) becomes a unique index", where, strings.Join(cols, ", "))
+		return
+	}
+	id, ok := specIDType(typ)
+	if !ok {
+		ns.notef("%s: ent does not support %s ids, using int instead", where, raw)
+	}
+	s.ID = id
+	if cols[0] != "id" {
+		s.IDStorageKey = cols[0]
+	}
+}
+
+// specIDType returns the ID type of a schema whose ID field has the given type, empty for the default int. ok is
+// false if ent does not support IDs of the type.
+func specIDType(typ string) (id string, ok bool) {
+	switch typ {
+	case "int", "int8", "int16", "int32", "uint", "uint8", "uint16", "uint32":
+		return "", true
+	case "int64", "uint64":
+		return "int64", true
+	case "uuid":
+		return "uuid", true
+	case "string", "text":
+		return "string", true
+	}
+	return "", false
+}
+
+// hasNillable reports whether the builder of fields of the given type has the Nillable option. The one of json
+// fields has none.
+func hasNillable(typ string) bool {
+	return typ != "json"
+}
+
+// hasUnique reports whether the builder of fields of the given type has the Unique option.
+func hasUnique(typ string) bool {
+	switch typ {
+	case "bool", "enum", "time", "json":
+		return false
+	}
+	return true
+}
+
+// setUnique makes the field of the schema unique. Fields of types ent cannot make unique get a unique index instead.
+func setUnique(s *SpecSchema, f *SpecField) {
+	if hasUnique(f.Type) {
+		f.Unique = true
+		return
+	}
+	s.Indexes = append(s.Indexes, &SpecIndex{Fields: []string{f.Name}, Unique: true})
+}
+
+// addIndex adds the index to the schema. Single-field unique indexes make the field unique instead, if its type
+// allows it. Indexes on the same fields and edges as another one are dropped.
+func addIndex(s *SpecSchema, si *SpecIndex) {
+	if len(si.Fields) == 1 && len(si.Edges) == 0 && si.Unique {
+		if f := specField(s, si.Fields[0]); f != nil && hasUnique(f.Type) {
+			f.Unique = true
+			return
+		}
+	}
+	for _, o := range s.Indexes {
+		if equalStrings(o.Fields, si.Fields) && equalStrings(o.Edges, si.Edges) {
+			return
+		}
+	}
+	s.Indexes = append(s.Indexes, si)
+}
+
+// trimTables drops the table names of the schemas that equal the ones ent derives.
+func trimTables(sf *SpecFile) {
+	for _, s := range sf.Schemas {
+		if s.Table == tableName(s.Name) {
+			s.Table = ""
+		}
+	}
+}
@@ -0,0 +1,37 @@
+package wapiti
+
+import (
+	"github.com/stretchr/testify/require"
+	"testing"
+)
+
+func TestImporterHelpers(t *testing.T) {
+	s := &SpecSchema{Name: "Pet", Fields: []*SpecField{{Name: "name", Type: "string"}, {Name: "born", Type: "time"}}}
+	// Unique indexes on a single field make the field unique if its type allows it.
+	addIndex(s, &SpecIndex{Fields: []string{"name"}, Unique: true, StorageKey: "pets_name"})
+	require.True(t, s.Fields[0].Unique)
+	require.Empty(t, s.Indexes)
+	setUnique(s, s.Fields[1])
+	require.False(t, s.Fields[1].Unique)
+	require.Equal(t, []*SpecIndex{{Fields: []string{"born"}, Unique: true}}, s.Indexes)
+	// Indexes on the same fields are added once.
+	addIndex(s, &SpecIndex{Fields: []string{"born"}, Unique: true, StorageKey: "pets_born"})
+	require.Len(t, s.Indexes, 1)
+
+	var ns notes
+	ns.primaryKey(s, "pets", []string{"pet_id"}, "uint64", "bigint unsigned")
+	require.Equal(t, "int64", s.ID)
+	require.Equal(t, "pet_id", s.IDStorageKey)
+	ns.primaryKey(s, "pets", []string{"pet_id"}, "float", "real")
+	require.Empty(t, s.ID)
+	ns.primaryKey(s, "logs", nil, "", "")
+	require.Equal(t, notes{
+		"pets: ent does not support real ids, using int instead",
+		"logs: the table has no primary key, ent adds an id column",
+	}, ns)
+
+	sf := &SpecFile{Schemas: []*SpecSchema{{Name: "Pet", Table: "pets"}, {Name: "User", Table: "accounts"}}}
+	trimTables(sf)
+	require.Empty(t, sf.Schemas[0].Table)
+	require.Equal(t, "accounts", sf.Schemas[1].Table)
+}
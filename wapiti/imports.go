@@ -0,0 +1,99 @@
+package wapiti
+
+import (
+	"go/ast"
+	"golang.org/x/tools/go/ast/astutil"
+	"path"
+	"sort"
+	"strconv"
+)
+
+// imports maps the package names wapiti generates code for to their import paths.
+var imports = map[string]string{
+	"ent":     "entgo.io/ent",
+	"schema":  "entgo.io/ent/schema",
+	"field":   "entgo.io/ent/schema/field",
+	"edge":    "entgo.io/ent/schema/edge",
+	"index":   "entgo.io/ent/schema/index",
+	"mixin":   "entgo.io/ent/schema/mixin",
+	"entsql":  "entgo.io/ent/dialect/entsql",
+	"uuid":    "github.com/google/uuid",
+	"time":    "time",
+	"regexp":  "regexp",
+	"dialect": "entgo.io/ent/dialect",
+}
+
+// fixImports adds the imports of the given packages used in the file and removes the ones no longer used. Imports not
+// listed in pkgs are left untouched. A package is not added if another import of the file already provides its name,
+// e.g. github.com/gofrs/uuid instead of github.com/google/uuid.
+func (s *source) fixImports(pkgs map[string]string) {
+	names := make([]string, 0, len(pkgs))
+	for n := range pkgs {
+		names = append(names, n)
+	}
+	sort.Strings(names)
+	used := usedPackages(s.file)
+	for _, n := range names {
+		p := pkgs[n]
+		spec := importSpec(s.file, p)
+		switch {
+		case used[n] && spec == nil && !importsName(s.file, n):
+			if path.Base(p) == n {
+				astutil.AddImport(s.fset, s.file, p)
+			} else {
+				astutil.AddNamedImport(s.fset, s.file, n, p)
+			}
+		// Only remove the import if it is not used under another name.
+		case !used[n] && spec != nil && importName(spec) == n:
+			if spec.Name == nil {
+				astutil.DeleteImport(s.fset, s.file, p)
+			} else {
+				astutil.DeleteNamedImport(s.fset, s.file, n, p)
+			}
+		}
+	}
+}
+
+// usedPackages returns the names of all packages referenced by selector expressions in the file. Identifiers declared
+// in the file itself (e.g. a local variable named 'field') are not counted.
+func usedPackages(f *ast.File) map[string]bool {
+	used := make(map[string]bool)
+	ast.Inspect(f, func(n ast.Node) bool {
+		if sel, ok := n.(*ast.SelectorExpr); ok {
+			if id, ok := sel.X.(*ast.Ident); ok && id.Obj == nil {
+				used[id.Name] = true
+			}
+		}
+		return true
+	})
+	return used
+}
+
+// importSpec returns the import of the given path. nil if the file does not import it.
+func importSpec(f *ast.File, p string) *ast.ImportSpec {
+	for _, s := range f.Imports {
+		if v, err := strconv.Unquote(s.Path.Value); err == nil && v == p {
+			return s
+		}
+	}
+	return nil
+}
+
+// importsName reports whether the file has an import referenced by the given name.
+func importsName(f *ast.File, n string) bool {
+	for _, s := range f.Imports {
+		if importName(s) == n {
+			return true
+		}
+	}
+	return false
+}
+
+// importName returns the name the import is referenced by in the file.
+func importName(s *ast.ImportSpec) string {
+	if s.Name != nil {
+		return s.Name.Name
+	}
+	v, _ := strconv.Unquote(s.Path.Value)
+	return path.Base(v)
+}
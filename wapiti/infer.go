@@ -0,0 +1,317 @@
+package wapiti
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/c-bata/go-prompt"
+	"github.com/logrusorgru/aurora/v3"
+	"io"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// uuidRgx matches the textual representation of a UUID.
+var uuidRgx = regexp.MustCompile("^[0-9a-fA-F]{8}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{12}$")
+
+// Kinds of JSON values as inferred by kindOf. Array kinds are the kind of their elements prefixed with "[]". The empty
+// kind is the one of null.
+const (
+	kindBool   = "bool"
+	kindInt    = "int"
+	kindFloat  = "float"
+	kindString = "string"
+	kindTime   = "time"
+	kindUUID   = "uuid"
+	kindObject = "object"
+	kindMixed  = "mixed"
+)
+
+// inferredKey collects what was seen of a key of the sampled records.
+type inferredKey struct {
+	name string
+	kind string
+	// first is the index of the record the key was first seen in. seen is the number of records having the key, null
+	// the number of records having it set to null.
+	first, seen, null int
+}
+
+// InferFields reads sample JSON documents from r and infers the fields of a schema from their keys. r holds a single
+// object, an array of objects or many objects one after another (NDJSON). Strings holding RFC 3339 timestamps become
+// time fields, strings holding UUIDs uuid fields and nested objects and arrays json fields. Keys missing in some records
+// become optional fields, keys set to null nillable ones. The fields are returned in the order their keys are first
+// seen. The key "id" is not returned as field, the type of the ID field of the schema is returned instead: one of the
+// idTypes or empty for the default.
+func InferFields(r io.Reader) ([]*Field, string, error) {
+	d := json.NewDecoder(r)
+	d.UseNumber()
+	var (
+		keys    []*inferredKey
+		byName  = make(map[string]*inferredKey)
+		records int
+	)
+	add := func(v interface{}) error {
+		o, ok := v.(map[string]interface{})
+		if !ok {
+			return fmt.Errorf("record %d is not a JSON object", records+1)
+		}
+		records++
+		for n, v := range o {
+			k, ok := byName[n]
+			if !ok {
+				k = &inferredKey{name: n, first: records}
+				byName[n] = k
+				keys = append(keys, k)
+			}
+			k.seen++
+			if v == nil {
+				k.null++
+				continue
+			}
+			k.kind = mergeKinds(k.kind, kindOf(v))
+		}
+		return nil
+	}
+	for {
+		var v interface{}
+		err := d.Decode(&v)
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return nil, "", fmt.Errorf("decoding JSON: %w", err)
+		}
+		vs, ok := v.([]interface{})
+		if !ok {
+			vs = []interface{}{v}
+		}
+		for _, v := range vs {
+			if err := add(v); err != nil {
+				return nil, "", err
+			}
+		}
+	}
+	if records == 0 {
+		return nil, "", errors.New("no JSON objects found")
+	}
+	// The keys of an object are decoded without order. Keys first seen in the same record are sorted by name.
+	sort.SliceStable(keys, func(i, j int) bool {
+		if keys[i].first != keys[j].first {
+			return keys[i].first < keys[j].first
+		}
+		return keys[i].name < keys[j].name
+	})
+	var (
+		fs    []*Field
+		id    string
+		names = map[string]bool{"id": true}
+	)
+	for _, k := range keys {
+		if k.name == "id" {
+			id = idTypeOf(k.kind)
+			continue
+		}
+		f := inferredField(k)
+		// Records holding null do not set the field on creation.
+		f.Optional = k.seen < records || k.null > 0
+		f.Nillable = k.null > 0 && hasNillable(f.Type)
+		f.Name = fieldName(k.name)
+		for i := 2; names[f.Name]; i++ {
+			f.Name = fieldName(k.name) + strconv.Itoa(i)
+		}
+		names[f.Name] = true
+		// Keep the key in the JSON encoding of the generated entity.
+		if f.Name != k.name {
+			f.StructTag = fmt.Sprintf("json:%q", k.name+",omitempty")
+		}
+		fs = append(fs, f)
+	}
+	return fs, id, nil
+}
+
+// kindOf returns the kind of the decoded JSON value.
+func kindOf(v interface{}) string {
+	switch v := v.(type) {
+	case bool:
+		return kindBool
+	case json.Number:
+		if _, err := v.Int64(); err == nil {
+			return kindInt
+		}
+		return kindFloat
+	case string:
+		if _, err := time.Parse(time.RFC3339Nano, v); err == nil {
+			return kindTime
+		}
+		if uuidRgx.MatchString(v) {
+			return kindUUID
+		}
+		return kindString
+	case []interface{}:
+		var k string
+		for _, e := range v {
+			if e != nil {
+				k = mergeKinds(k, kindOf(e))
+			}
+		}
+		return "[]" + k
+	case map[string]interface{}:
+		return kindObject
+	}
+	return kindMixed
+}
+
+// mergeKinds returns the kind holding values of both kinds: integers widen to floats, timestamps and UUIDs to strings.
+// Values without a common kind are mixed.
+func mergeKinds(a, b string) string {
+	switch {
+	case a == "" || a == b:
+		return b
+	case b == "":
+		return a
+	case strings.HasPrefix(a, "[]") && strings.HasPrefix(b, "[]"):
+		// An empty array holds any kind of element.
+		return "[]" + mergeKinds(a[2:], b[2:])
+	}
+	widen := map[string]string{kindInt: kindFloat, kindTime: kindString, kindUUID: kindString}
+	for _, ks := range [][2]string{{a, b}, {b, a}} {
+		if widen[ks[0]] == ks[1] || widen[ks[0]] != "" && widen[ks[0]] == widen[ks[1]] {
+			return widen[ks[0]]
+		}
+	}
+	return kindMixed
+}
+
+// inferredField returns the field of the given type holding the values of the key.
+func inferredField(k *inferredKey) *Field {
+	switch k.kind {
+	case kindBool, kindInt, kindFloat, kindString, kindTime, kindUUID:
+		return &Field{Type: k.kind}
+	case "":
+		// Only null was seen.
+		return &Field{Type: defaultType}
+	case kindObject:
+		return &Field{Type: "json", GoType: "map[string]interface{}"}
+	case kindMixed:
+		return &Field{Type: "json", GoType: "json.RawMessage", Imports: map[string]string{"json": "encoding/json"}}
+	}
+	// Arrays of timestamps and UUIDs are kept as strings, there is no need to import the packages of their types.
+	switch strings.TrimPrefix(k.kind, "[]") {
+	case kindBool:
+		return &Field{Type: "json", GoType: "[]bool"}
+	case kindInt:
+		return &Field{Type: "json", GoType: "[]int"}
+	case kindFloat:
+		return &Field{Type: "json", GoType: "[]float64"}
+	case kindString, kindTime, kindUUID:
+		return &Field{Type: "json", GoType: "[]string"}
+	case kindObject:
+		return &Field{Type: "json", GoType: "[]map[string]interface{}"}
+	}
+	return &Field{Type: "json", GoType: "[]interface{}"}
+}
+
+// idTypeOf returns the type of the ID field holding values of the given kind. Empty for the default int.
+func idTypeOf(kind string) string {
+	// Timestamps are kept as they are.
+	if kind == kindTime {
+		kind = kindString
+	}
+	id, _ := specIDType(kind)
+	return id
+}
+
+// fieldName returns the name of the field storing the JSON key: keys are converted to snake case, characters not
+// allowed in field names are dropped.
+func fieldName(key string) string {
+	n := strings.TrimRight(strings.TrimLeft(invalidNameRgx.ReplaceAllString(snake(key), "_"), "_0123456789"), "_")
+	if n == "" {
+		return "field"
+	}
+	return strings.ToLower(n)
+}
+
+// inferActions are the answers to a field proposed by InferSchema.
+var inferActions = []prompt.Suggest{
+	{Text: "add", Description: "Add the field as proposed"},
+	{Text: "edit", Description: "Change the name, type or options before adding the field"},
+	{Text: "skip", Description: "Do not add the field"},
+	{Text: "stop", Description: "Do not add this and the remaining fields"},
+}
+
+// InferSchema proposes the fields inferred from sample data to the node with the given name one by one and adds the
+// ones the user confirms. The node is created with the given ID type if there is none. Fields the node already has are
+// skipped. Like the wizard, it shows the changes and asks for confirmation before writing a file. On a dry run the
+// changes are staged and shown once all fields were proposed.
+func (w *Wapiti) InferSchema(name, idType string, fs []*Field) error {
+	// Nothing is written on a dry run, the fields are proposed to the staged node instead.
+	if w.cfg.DryRun && w.staged == nil {
+		return w.stage(func() error {
+			return w.InferSchema(name, idType, fs)
+		})
+	}
+	w.interactive = true
+	defer func() { w.interactive = false }()
+	s := w.LookupNode(name)
+	if s == nil {
+		if err := w.CreateSchema(name, idType); err != nil {
+			return err
+		}
+		s = w.LookupNode(name)
+	}
+	for i := 0; i < len(fs); i++ {
+		f := fs[i]
+		if hasField(s, f.Name) {
+			fmt.Println(aurora.Yellow(fmt.Sprintf("%s already has a field %s", s.Name, f.Name)))
+			continue
+		}
+		f.Schema = s
+		code, err := f.code()
+		if err != nil {
+			return err
+		}
+		switch a := ask(append(inferActions, shortcuts...), "Add %s (add/edit/skip/stop) [%s]:", aurora.Yellow(code), aurora.Yellow("add")); a {
+		case "", "add":
+		case "edit":
+			if n := ask(nil, "Name of the field [%s]:", aurora.Yellow(f.Name)); n != "" {
+				f.Name = n
+			}
+			if !fieldNameRgx.MatchString(f.Name) {
+				return errFieldName
+			}
+			if err := w.askFieldType(f); err != nil {
+				return err
+			}
+			if err := w.askFieldOptions(f); err != nil {
+				return err
+			}
+		case "skip":
+			continue
+		case "stop":
+			return nil
+		default:
+			if !w.shortcut(a) {
+				return fmt.Errorf("unknown action %q", a)
+			}
+			// The node is gone if the user undid its creation. Otherwise propose the field again.
+			if s = w.LookupNode(name); s == nil {
+				return nil
+			}
+			i--
+			continue
+		}
+		_, err = w.AddField(f)
+		if w.skip(err) {
+			continue
+		}
+		if err != nil {
+			return err
+		}
+		fmt.Printf(fieldAddedFormat, aurora.Cyan(f.Name), aurora.Cyan(s.Name))
+		s = w.LookupNode(name)
+	}
+	return nil
+}
@@ -0,0 +1,67 @@
+package wapiti
+
+import (
+	"github.com/stretchr/testify/require"
+	"strings"
+	"testing"
+)
+
+func TestInferFields(t *testing.T) {
+	fs, id, err := InferFields(strings.NewReader(`
+{"id": "9b2ef3b8-5a0c-4a4e-8d39-6a1f6b1c2d3e", "name": "Kitty", "age": 3, "weight": 4, "born": "2019-05-04T12:00:00Z", "tags": ["cute"], "meta": {"a": 1}}
+{"id": "7d0f5a4e-1c2b-4f3a-9e8d-7c6b5a4d3e2f", "name": "Rex", "age": 5, "weight": 12.5, "born": "unknown", "tags": [], "owner": null, "chipId": "0b6b8c5e-2f1a-4c3d-9e8f-7a6b5c4d3e2f"}
+{"id": "1e2d3c4b-5a69-4788-9a0b-1c2d3e4f5a6b", "name": "Tom", "age": 1, "weight": 3, "born": "2021-01-01T00:00:00.5+01:00", "tags": null, "owner": "Jane", "chipId": 7}
+`))
+	require.NoError(t, err)
+	require.Equal(t, "uuid", id)
+	require.Equal(t, []*Field{
+		{Name: "age", Type: "int"},
+		{Name: "born", Type: "string"},
+		{Name: "meta", Type: "json", GoType: "map[string]interface{}", Optional: true},
+		{Name: "name", Type: "string"},
+		{Name: "tags", Type: "json", GoType: "[]string", Optional: true},
+		{Name: "weight", Type: "float"},
+		{Name: "chip_id", Type: "json", GoType: "json.RawMessage", Imports: map[string]string{"json": "encoding/json"}, Optional: true, StructTag: `json:"chipId,omitempty"`},
+		{Name: "owner", Type: "string", Optional: true, Nillable: true},
+	}, fs)
+	for _, f := range fs {
+		_, err := f.code()
+		require.NoError(t, err)
+	}
+
+	fs, id, err = InferFields(strings.NewReader(`[{"id": 1, "created": "2021-01-01T00:00:00Z", "1st place": true, "first_place": false, "nested": [[1]], "only": null}]`))
+	require.NoError(t, err)
+	require.Empty(t, id)
+	require.Equal(t, []*Field{
+		{Name: "st_place", Type: "bool", StructTag: `json:"1st place,omitempty"`},
+		{Name: "created", Type: "time"},
+		{Name: "first_place", Type: "bool"},
+		{Name: "nested", Type: "json", GoType: "[]interface{}"},
+		{Name: "only", Type: "string", Optional: true, Nillable: true},
+	}, fs)
+
+	_, _, err = InferFields(strings.NewReader(`{"a": 1} [2]`))
+	require.EqualError(t, err, "record 2 is not a JSON object")
+	_, _, err = InferFields(strings.NewReader(`{"a": `))
+	require.EqualError(t, err, "decoding JSON: unexpected EOF")
+	_, _, err = InferFields(strings.NewReader(` `))
+	require.EqualError(t, err, "no JSON objects found")
+}
+
+func TestMergeKinds(t *testing.T) {
+	for _, tt := range [][3]string{
+		{"", "int", "int"},
+		{"int", "", "int"},
+		{"int", "float", "float"},
+		{"float", "int", "float"},
+		{"time", "string", "string"},
+		{"uuid", "time", "string"},
+		{"int", "string", "mixed"},
+		{"[]int", "[]", "[]int"},
+		{"[]int", "[]float", "[]float"},
+		{"[]int", "int", "mixed"},
+		{"object", "[]object", "mixed"},
+	} {
+		require.Equal(t, tt[2], mergeKinds(tt[0], tt[1]), "%s + %s", tt[0], tt[1])
+	}
+}
@@ -0,0 +1,205 @@
+package wapiti
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/masseelch/wapiti/wapiti/config"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// journalSize is the maximum number of entries kept in the journal.
+const journalSize = 100
+
+// journal records the changes wapiti made to the schema files, so that they can be undone and redone.
+type journal struct {
+	Entries []*journalEntry `json:"entries"`
+	// Cursor is the number of applied entries. Entries after the cursor have been undone and can be redone.
+	Cursor int `json:"cursor"`
+}
+
+// journalEntry is a single change of one or more files.
+type journalEntry struct {
+	Time  time.Time      `json:"time"`
+	Files []*journalFile `json:"files"`
+}
+
+// journalFile holds the contents of a file before and after a change. nil contents mean the file did not exist.
+type journalFile struct {
+	Path   string  `json:"path"`
+	Before *string `json:"before"`
+	After  *string `json:"after"`
+}
+
+// journalPath returns the path of the journal. It is stored next to the schema directory.
+func (w *Wapiti) journalPath() string {
+	return filepath.Join(filepath.Dir(filepath.Clean(w.cfg.SchemaPath)), ".wapiti", "journal.json")
+}
+
+// loadJournal reads the journal from disk. A missing journal is returned empty.
+func (w *Wapiti) loadJournal() (*journal, error) {
+	j := new(journal)
+	b, err := ioutil.ReadFile(w.journalPath())
+	if os.IsNotExist(err) {
+		return j, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("reading journal: %w", err)
+	}
+	if err := json.Unmarshal(b, j); err != nil {
+		return nil, fmt.Errorf("parsing journal: %w", err)
+	}
+	return j, nil
+}
+
+// saveJournal writes the journal to disk.
+func (w *Wapiti) saveJournal(j *journal) error {
+	b, err := json.MarshalIndent(j, "", "  ")
+	if err != nil {
+		return fmt.Errorf("encoding journal: %w", err)
+	}
+	p := w.journalPath()
+	if err := os.MkdirAll(filepath.Dir(p), 0755); err != nil {
+		return fmt.Errorf("creating journal directory: %w", err)
+	}
+	if err := ioutil.WriteFile(p, b, 0644); err != nil {
+		return fmt.Errorf("writing journal: %w", err)
+	}
+	return nil
+}
+
+// record adds the given file changes as a new entry to the journal. Entries that have been undone are discarded.
+func (w *Wapiti) record(fs ...*journalFile) error {
+	j, err := w.loadJournal()
+	if err != nil {
+		return err
+	}
+	j.Entries = append(j.Entries[:j.Cursor], &journalEntry{Time: time.Now(), Files: fs})
+	if len(j.Entries) > journalSize {
+		j.Entries = j.Entries[len(j.Entries)-journalSize:]
+	}
+	j.Cursor = len(j.Entries)
+	return w.saveJournal(j)
+}
+
+// Undo reverts the last change recorded in the journal. Calls Reload afterwards.
+func (w *Wapiti) Undo() error {
+	if err := w.undo(); err != nil {
+		return err
+	}
+	return w.Reload()
+}
+
+// Redo re-applies the last change reverted by Undo. Calls Reload afterwards.
+func (w *Wapiti) Redo() error {
+	if err := w.redo(); err != nil {
+		return err
+	}
+	return w.Reload()
+}
+
+// UndoFiles reverts the last change recorded in the journal of the schema directory. The schemas are not loaded, so
+// a change that left code that does not compile can be undone.
+func UndoFiles(cfg *config.Config) error {
+	return (&Wapiti{cfg: cfg}).undo()
+}
+
+// RedoFiles re-applies the last change reverted by undo without loading the schemas.
+func RedoFiles(cfg *config.Config) error {
+	return (&Wapiti{cfg: cfg}).redo()
+}
+
+// undo reverts the last change recorded in the journal.
+func (w *Wapiti) undo() error {
+	j, err := w.loadJournal()
+	if err != nil {
+		return err
+	}
+	if j.Cursor == 0 {
+		return errors.New("nothing to undo")
+	}
+	if err := w.replay(j.Entries[j.Cursor-1], true); err != nil {
+		return err
+	}
+	j.Cursor--
+	return w.saveJournal(j)
+}
+
+// redo re-applies the last change reverted by undo.
+func (w *Wapiti) redo() error {
+	j, err := w.loadJournal()
+	if err != nil {
+		return err
+	}
+	if j.Cursor == len(j.Entries) {
+		return errors.New("nothing to redo")
+	}
+	if err := w.replay(j.Entries[j.Cursor], false); err != nil {
+		return err
+	}
+	j.Cursor++
+	return w.saveJournal(j)
+}
+
+// replay restores the files of the entry to their contents before (undo) or after the change. It refuses to do so if
+// any of the files has been modified since, since that would discard changes made elsewhere.
+func (w *Wapiti) replay(e *journalEntry, undo bool) error {
+	current := make([][]byte, len(e.Files))
+	for i, f := range e.Files {
+		// The file must still look like it did after the change we undo, or before the change we redo.
+		want := f.Before
+		if undo {
+			want = f.After
+		}
+		b, err := ioutil.ReadFile(f.Path)
+		switch {
+		case os.IsNotExist(err):
+			if want != nil {
+				return fmt.Errorf("file %s has been removed since the change", f.Path)
+			}
+		case err != nil:
+			return fmt.Errorf("reading file %s: %w", f.Path, err)
+		case want == nil || *want != string(b):
+			return fmt.Errorf("file %s has been modified since the change", f.Path)
+		}
+		current[i] = b
+	}
+	for i, f := range e.Files {
+		to := f.After
+		if undo {
+			to = f.Before
+		}
+		switch {
+		case w.cfg.DryRun:
+			var b []byte
+			if to != nil {
+				b = []byte(*to)
+			}
+			fmt.Print(colorDiff(diff(f.Path, current[i], b)))
+		case to == nil:
+			if err := os.Remove(f.Path); err != nil {
+				return fmt.Errorf("removing file %s: %w", f.Path, err)
+			}
+		default:
+			if err := ioutil.WriteFile(f.Path, []byte(*to), 0644); err != nil {
+				return fmt.Errorf("writing file %s: %w", f.Path, err)
+			}
+		}
+	}
+	if w.cfg.DryRun {
+		return ErrNotWritten
+	}
+	return nil
+}
+
+// contents returns a pointer to the contents of a file for the journal. nil if the file does not exist.
+func contents(b []byte, exists bool) *string {
+	if !exists {
+		return nil
+	}
+	s := string(b)
+	return &s
+}
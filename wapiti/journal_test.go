@@ -0,0 +1,61 @@
+package wapiti
+
+import (
+	"github.com/masseelch/wapiti/wapiti/config"
+	"github.com/stretchr/testify/require"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestJournal(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "schema")
+	require.NoError(t, os.Mkdir(dir, 0755))
+	w := &Wapiti{cfg: &config.Config{SchemaPath: dir}}
+	p := filepath.Join(dir, "pet.go")
+	require.NoError(t, w.write(p, []byte("v1")))
+	require.NoError(t, w.write(p, []byte("v2")))
+	require.FileExists(t, filepath.Join(filepath.Dir(dir), ".wapiti", "journal.json"))
+
+	j, err := w.loadJournal()
+	require.NoError(t, err)
+	require.Len(t, j.Entries, 2)
+	require.Equal(t, 2, j.Cursor)
+	require.Nil(t, j.Entries[0].Files[0].Before)
+
+	// Undo both changes, the file is removed again.
+	require.NoError(t, w.replay(j.Entries[1], true))
+	requireContents(t, p, "v1")
+	require.NoError(t, w.replay(j.Entries[0], true))
+	require.NoFileExists(t, p)
+	// Redo the first one.
+	require.NoError(t, w.replay(j.Entries[0], false))
+	requireContents(t, p, "v1")
+	// Changes made elsewhere are not overwritten.
+	require.NoError(t, ioutil.WriteFile(p, []byte("v3"), 0644))
+	require.Error(t, w.replay(j.Entries[0], true))
+	requireContents(t, p, "v3")
+
+	// Recording discards entries that were undone.
+	j.Cursor = 1
+	require.NoError(t, w.saveJournal(j))
+	require.NoError(t, w.write(p, []byte("v4")))
+	j, err = w.loadJournal()
+	require.NoError(t, err)
+	require.Len(t, j.Entries, 2)
+	require.Equal(t, "v4", *j.Entries[1].Files[0].After)
+
+	// The files are restored without loading the schemas, which do not compile here.
+	require.NoError(t, UndoFiles(w.cfg))
+	requireContents(t, p, "v3")
+	require.NoError(t, RedoFiles(w.cfg))
+	requireContents(t, p, "v4")
+	require.EqualError(t, RedoFiles(w.cfg), "nothing to redo")
+}
+
+func requireContents(t *testing.T, p, want string) {
+	b, err := ioutil.ReadFile(p)
+	require.NoError(t, err)
+	require.Equal(t, want, string(b))
+}
@@ -3,15 +3,17 @@ package wapiti
 import (
 	"bytes"
 	"entgo.io/ent/entc/load"
+	"errors"
 	"fmt"
+	"github.com/c-bata/go-prompt"
 	"github.com/logrusorgru/aurora/v3"
 	"go/ast"
-	"go/parser"
-	"go/printer"
-	"go/token"
 	"io/ioutil"
 	"os"
 	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
 	"strings"
 	"text/template"
 )
@@ -34,6 +36,49 @@ type {{ . }} struct {
 }
 `))
 
+// builders maps the field types offered by the wizard to the ent field builder functions.
+var builders = map[string]string{
+	"int": "Int", "uint": "Uint",
+	"int8": "Int8", "int16": "Int16", "int32": "Int32", "int64": "Int64",
+	"uint8": "Uint8", "uint16": "Uint16", "uint32": "Uint32", "uint64": "Uint64",
+	"float": "Float", "float32": "Float32",
+	"bool":   "Bool",
+	"string": "String", "text": "Text",
+	"time":   "Time",
+	"uuid":   "UUID",
+	"[]byte": "Bytes",
+	"json":   "JSON",
+	"enum":   "Enum",
+	"other":  "Other",
+}
+
+// fieldOptions are the options of a field wapiti manages. All other options are kept when a field is updated.
+var fieldOptions = map[string]bool{
+	"Values":        true,
+	"NamedValues":   true,
+	"GoType":        true,
+	"Default":       true,
+	"UpdateDefault": true,
+	"Optional":      true,
+	"Nillable":      true,
+	"Immutable":     true,
+	"NotEmpty":      true,
+	"MinLen":        true,
+	"MaxLen":        true,
+	"Match":         true,
+	"Positive":      true,
+	"Negative":      true,
+	"Min":           true,
+	"Max":           true,
+	"Range":         true,
+	"Unique":        true,
+	"Sensitive":     true,
+	"StorageKey":    true,
+	"StructTag":     true,
+	"SchemaType":    true,
+	"Comment":       true,
+}
+
 var fieldsTpl = template.Must(template.New("fields").Parse(`
 // Fields of the {{ . }}.
 func ({{ . }}) Fields() []ent.Field {
@@ -41,53 +86,751 @@ func ({{ . }}) Fields() []ent.Field {
 }
 `))
 
-// CreateSchema creates a new schema with the given name and writes it to file. Calls Reload afterwards.
-func (w *Wapiti) CreateSchema(name string) error {
+var edgesTpl = template.Must(template.New("edges").Parse(`
+// Edges of the {{ . }}.
+func ({{ . }}) Edges() []ent.Edge {
+	return nil
+}
+`))
+
+var indexesTpl = template.Must(template.New("indexes").Parse(`
+// Indexes of the {{ . }}.
+func ({{ . }}) Indexes() []ent.Index {
+	return nil
+}
+`))
+
+var annotationsTpl = template.Must(template.New("annotations").Parse(`
+// Annotations of the {{ . }}.
+func ({{ . }}) Annotations() []schema.Annotation {
+	return nil
+}
+`))
+
+// schemaFile returns the path of the file a new schema with the given name is written to.
+func (w *Wapiti) schemaFile(name string) string {
+	return filepath.Join(w.cfg.SchemaPath, strings.ToLower(name+".go"))
+}
+
+// CreateSchema creates a new schema with the given name and writes it to file. idType is the type of the ID field,
+// one of the idTypes. The ID field is only added if it is not the default int. Calls Reload afterwards.
+func (w *Wapiti) CreateSchema(name, idType string) error {
+	return w.createSchema(name, idType, "")
+}
+
+// createSchema works like CreateSchema. If idKey is not empty the ID field is added and stored in the column of that
+// name.
+func (w *Wapiti) createSchema(name, idType, idKey string) error {
+	if !nodeNameRgx.MatchString(name) {
+		return errSchemaName
+	}
+	if w.LookupNode(name) != nil {
+		return fmt.Errorf("schema %s already exists", name)
+	}
+	id, err := idField(idType, idKey)
+	if err != nil {
+		return err
+	}
+	// The file name is lower case, a schema whose name differs only in case must not overwrite another one.
+	f := w.schemaFile(name)
+	if _, err := os.Stat(f); err == nil || w.staged[f] != nil {
+		return fmt.Errorf("file %s already exists", f)
+	}
 	b := new(bytes.Buffer)
 	if err := schemaTpl.Execute(b, name); err != nil {
 		return fmt.Errorf("executing template %s: %w", name, err)
 	}
-	f := filepath.Join(w.cfg.SchemaPath, strings.ToLower(name+".go"))
-	if err := ioutil.WriteFile(f, b.Bytes(), 0644); err != nil {
-		return fmt.Errorf("writing file %s: %w", f, err)
+	src, err := parseSource(f, b.Bytes())
+	if err != nil {
+		return err
+	}
+	if id != nil {
+		code, err := id.code()
+		if err != nil {
+			return err
+		}
+		if err := src.appendToMethod(name, "Fields", fieldsTpl, "[]ent.Field", code); err != nil {
+			return err
+		}
+	}
+	if err := w.save(src); err != nil {
+		return err
+	}
+	// Staged schemas are not loaded before the changes are written, until then they are known without fields.
+	if w.staged != nil {
+		w.spec.Schemas = append(w.spec.Schemas, &load.Schema{Name: name})
 	}
 	fmt.Printf(schemaCreatedFormat, aurora.Cyan(f))
-	return w.Reload()
+	return nil
+}
+
+// idTypes are the types of the ID field a schema can be created with.
+var idTypes = []prompt.Suggest{
+	{Text: "int", Description: "Auto-incrementing integer (ent default)"},
+	{Text: "uuid", Description: "Random UUID generated by uuid.New"},
+	{Text: "string", Description: "String set by the application"},
+	{Text: "int64", Description: "64-bit integer set by the application"},
+}
+
+// idField returns the ID field of the given type stored in the column key, empty for the default id. nil for the
+// default int ID.
+func idField(typ, key string) (*Field, error) {
+	if key == "id" {
+		key = ""
+	}
+	var f *Field
+	switch typ {
+	case "", "int":
+		if key == "" {
+			return nil, nil
+		}
+		f = &Field{Name: "id", Type: "int"}
+	case "uuid":
+		f = &Field{Name: "id", Type: "uuid", Default: "uuid.New"}
+	case "string", "int64":
+		f = &Field{Name: "id", Type: typ}
+	default:
+		return nil, fmt.Errorf("unknown ID type %q: choose one of int, uuid, string and int64", typ)
+	}
+	f.StorageKey = key
+	return f, nil
 }
 
 // AddField adds the field to the schema. Calls Reload afterwards.
 func (w *Wapiti) AddField(f *Field) (*load.Field, error) {
-	// Find 'Fields()'-method of our schema.
-	file, fieldsDecl := w.fieldsMethod(f.Schema)
-	// If there is no 'Fields()'-method add one.
-	if fieldsDecl == nil {
-		// Get the file our schema resides in.
-		file = w.file(f.Schema)
-		// Add the 'Fields()'-method.
-		b := bytes.NewBuffer([]byte("package main\n"))
-		if err := fieldsTpl.Execute(b, f.Schema.Name); err != nil {
-			return nil, err
-		}
-		expr, err := parser.ParseFile(token.NewFileSet(), "", b.String(), parser.ParseComments)
+	code, err := f.code()
+	if err != nil {
+		return nil, err
+	}
+	if err := w.appendToMethod(f.Schema, "Fields", fieldsTpl, "[]ent.Field", code, f.Imports); err != nil {
+		return nil, err
+	}
+	return w.lookupField(f.Schema.Name, f.Name), nil
+}
+
+// UpdateField replaces the field with the given name of the schema by f. The options wapiti manages, including the
+// validators and the Go type, are replaced by the ones of f, use FieldOf to start from the existing field. Other
+// options of the existing field (e.g. annotations) are kept. Calls Reload afterwards.
+func (w *Wapiti) UpdateField(s *load.Schema, name string, f *Field) (*load.Field, error) {
+	code, err := f.code()
+	if err != nil {
+		return nil, err
+	}
+	src, lit, i, err := w.fieldElem(s, name)
+	if err != nil {
+		return nil, err
+	}
+	for _, o := range src.options(lit.Elts[i]) {
+		if !fieldOptions[o.name] {
+			code += "." + o.code
+		}
+	}
+	if err := src.replaceElem(lit, i, code); err != nil {
+		return nil, err
+	}
+	src.imports = f.Imports
+	if err := w.save(src); err != nil {
+		return nil, err
+	}
+	return w.lookupField(s.Name, f.Name), nil
+}
+
+// RemoveField removes the field with the given name from the schema. Calls Reload afterwards.
+func (w *Wapiti) RemoveField(s *load.Schema, name string) error {
+	src, lit, i, err := w.fieldElem(s, name)
+	if err != nil {
+		return err
+	}
+	if err := src.removeElem(lit, i); err != nil {
+		return err
+	}
+	return w.save(src)
+}
+
+// fieldElem locates the element declaring the field with the given name in the 'Fields()'-method of the schema.
+func (w *Wapiti) fieldElem(s *load.Schema, name string) (*source, *ast.CompositeLit, int, error) {
+	file, _ := w.method(s, "Fields")
+	if file == nil {
+		return nil, nil, 0, fmt.Errorf("schema %s has no Fields() method", s.Name)
+	}
+	src, err := w.source(file)
+	if err != nil {
+		return nil, nil, 0, err
+	}
+	lit, i := src.elem(src.method(s.Name, "Fields"), "field", name)
+	if i < 0 {
+		return nil, nil, 0, fmt.Errorf("field %s is not declared in %s.Fields()", name, s.Name)
+	}
+	return src, lit, i, nil
+}
+
+// AddEdge adds the edge to the schema. Calls Reload afterwards.
+func (w *Wapiti) AddEdge(e *Edge) (*load.Edge, error) {
+	if w.LookupNode(e.Type) == nil {
+		return nil, fmt.Errorf("unknown schema %s", e.Type)
+	}
+	code, err := e.code()
+	if err != nil {
+		return nil, err
+	}
+	if err := w.appendToMethod(e.Schema, "Edges", edgesTpl, "[]ent.Edge", code, nil); err != nil {
+		return nil, err
+	}
+	return w.lookupEdge(e.Schema.Name, e.Name), nil
+}
+
+// AddBackRef adds an edge with the given name to the schema the given edge points to, referencing the given edge.
+// Calls Reload afterwards.
+func (w *Wapiti) AddBackRef(e *Edge, name string, unique bool) (*load.Edge, error) {
+	t := w.LookupNode(e.Type)
+	if t == nil {
+		return nil, fmt.Errorf("unknown schema %s", e.Type)
+	}
+	return w.AddEdge(&Edge{
+		Schema:  t,
+		Name:    name,
+		Type:    e.Schema.Name,
+		Inverse: true,
+		Ref:     e.Name,
+		Unique:  unique,
+	})
+}
+
+// AddEdgeWithBackRef adds the edge to the schema and, if name is not empty, a back-reference with the given name to
+// the schema the edge points to. Both are written as one change. Calls Reload afterwards.
+func (w *Wapiti) AddEdgeWithBackRef(e *Edge, name string, unique bool) (*load.Edge, error) {
+	if err := w.stage(func() error {
+		if _, err := w.AddEdge(e); err != nil || name == "" {
+			return err
+		}
+		_, err := w.AddBackRef(e, name, unique)
+		return err
+	}); err != nil {
+		return nil, err
+	}
+	return w.lookupEdge(e.Schema.Name, e.Name), nil
+}
+
+// AddIndex adds the index to the schema. Calls Reload afterwards.
+func (w *Wapiti) AddIndex(i *Index) (*load.Index, error) {
+	code, err := i.code()
+	if err != nil {
+		return nil, err
+	}
+	if err := w.appendToMethod(i.Schema, "Indexes", indexesTpl, "[]ent.Index", code, nil); err != nil {
+		return nil, err
+	}
+	if s := w.LookupNode(i.Schema.Name); s != nil && len(s.Indexes) > 0 {
+		return s.Indexes[len(s.Indexes)-1], nil
+	}
+	return nil, nil
+}
+
+// appendToMethod adds elem to the slice returned by the given method of the schema. If the schema has no such method
+// it is created by executing tpl. typ is the type of the returned slice, pkgs are the import paths of packages elem
+// references besides the well-known ones. Calls Reload afterwards.
+func (w *Wapiti) appendToMethod(s *load.Schema, method string, tpl *template.Template, typ, elem string, pkgs map[string]string) error {
+	// Find the method of our schema.
+	file, _ := w.method(s, method)
+	// If there is no such method use the file our schema resides in.
+	if file == nil {
+		file = w.file(s)
+	}
+	if file == nil {
+		return fmt.Errorf("no file found for schema %s", s.Name)
+	}
+	src, err := w.source(file)
+	if err != nil {
+		return err
+	}
+	if err := src.appendToMethod(s.Name, method, tpl, typ, elem); err != nil {
+		return err
+	}
+	src.imports = pkgs
+	return w.save(src)
+}
+
+// appendToMethod adds elem to the slice returned by the given method of the receiver recv. If there is no such method
+// it is created by executing tpl. typ is the type of the returned slice.
+func (s *source) appendToMethod(recv, method string, tpl *template.Template, typ, elem string) error {
+	decl := s.method(recv, method)
+	// If there is no such method add one.
+	if decl == nil {
+		b := new(bytes.Buffer)
+		if err := tpl.Execute(b, recv); err != nil {
+			return fmt.Errorf("executing template %s: %w", tpl.Name(), err)
+		}
+		if err := s.appendDecl(b.String()); err != nil {
+			return err
+		}
+		decl = s.method(recv, method)
+	}
+	return s.appendElem(decl, typ, elem)
+}
+
+// code returns the ent builder chain for the field, e.g. 'field.String("name").Optional()'.
+func (f *Field) code() (string, error) {
+	if !fieldNameRgx.MatchString(f.Name) {
+		return "", errFieldName
+	}
+	b, ok := builders[f.Type]
+	if !ok {
+		return "", fmt.Errorf("unknown field type %q", f.Type)
+	}
+	var c string
+	switch f.Type {
+	case "uuid", "json", "other":
+		t := f.GoType
+		if t == "" && f.Type == "uuid" {
+			t = "uuid.UUID"
+		}
+		v, err := typeValue(f.Type, t)
 		if err != nil {
-			return nil, err
+			return "", err
+		}
+		if f.Type == "other" && len(f.SchemaType) == 0 {
+			return "", fmt.Errorf("other fields need a schema type")
+		}
+		c = fmt.Sprintf("field.%s(%q, %s)", b, f.Name, v)
+	case "enum":
+		c = fmt.Sprintf("field.%s(%q)", b, f.Name)
+		// The values of enums with a Go type are given by the type.
+		if f.GoType != "" {
+			break
+		}
+		e, err := enumCode(f)
+		if err != nil {
+			return "", err
+		}
+		c += e
+	default:
+		c = fmt.Sprintf("field.%s(%q)", b, f.Name)
+	}
+	if f.GoType != "" {
+		switch f.Type {
+		case "uuid", "json", "other":
+		default:
+			v, err := typeValue(f.Type, f.GoType)
+			if err != nil {
+				return "", err
+			}
+			c += ".GoType(" + v + ")"
+		}
+	}
+	if f.Default != "" {
+		c += ".Default(" + f.Default + ")"
+	}
+	if f.UpdateDefault != "" {
+		if f.Type != "time" {
+			return "", fmt.Errorf("only time fields can have an update default")
+		}
+		c += ".UpdateDefault(" + f.UpdateDefault + ")"
+	}
+	for _, v := range f.Validators {
+		c += "." + v.code()
+	}
+	if f.Optional {
+		c += ".Optional()"
+	}
+	if f.Nillable {
+		if f.Type == "json" {
+			return "", fmt.Errorf("json fields cannot be nillable")
+		}
+		c += ".Nillable()"
+	}
+	if f.Immutable {
+		c += ".Immutable()"
+	}
+	if f.Unique {
+		switch f.Type {
+		case "bool", "enum", "time", "json":
+			return "", fmt.Errorf("%s fields cannot be unique", f.Type)
+		}
+		c += ".Unique()"
+	}
+	if f.Sensitive {
+		if f.Type != "string" && f.Type != "text" {
+			return "", fmt.Errorf("only string fields can be sensitive")
+		}
+		c += ".Sensitive()"
+	}
+	if f.StorageKey != "" {
+		c += fmt.Sprintf(".StorageKey(%q)", f.StorageKey)
+	}
+	if f.StructTag != "" {
+		c += ".StructTag(" + rawQuote(f.StructTag) + ")"
+	}
+	if len(f.SchemaType) > 0 {
+		if f.Type == "bool" {
+			return "", fmt.Errorf("bool fields cannot have a schema type")
+		}
+		c += ".SchemaType(" + schemaTypeCode(f.SchemaType) + ")"
+	}
+	if f.Comment != "" {
+		c += fmt.Sprintf(".Comment(%q)", f.Comment)
+	}
+	return c, nil
+}
+
+// dialects are the dialects a schema type can be given for, mapped to the constants of the dialect package.
+var dialects = []struct{ name, ident string }{
+	{"mysql", "dialect.MySQL"},
+	{"postgres", "dialect.Postgres"},
+	{"sqlite3", "dialect.SQLite"},
+}
+
+// schemaTypeCode returns the map literal of the schema types per dialect. Known dialects use the constants of the
+// dialect package and are listed first.
+func schemaTypeCode(st map[string]string) string {
+	var kvs []string
+	for _, d := range dialects {
+		if t, ok := st[d.name]; ok {
+			kvs = append(kvs, fmt.Sprintf("%s: %q", d.ident, t))
+		}
+	}
+	var other []string
+	for k := range st {
+		if dialectIdent(k) == "" {
+			other = append(other, k)
+		}
+	}
+	sort.Strings(other)
+	for _, k := range other {
+		kvs = append(kvs, fmt.Sprintf("%q: %q", k, st[k]))
+	}
+	return "map[string]string{" + strings.Join(kvs, ", ") + "}"
+}
+
+// dialectIdent returns the constant of the dialect package for the dialect with the given name. Empty if there is none.
+func dialectIdent(name string) string {
+	for _, d := range dialects {
+		if d.name == name {
+			return d.ident
+		}
+	}
+	return ""
+}
+
+// rawQuote returns s as raw string literal, or as interpreted string literal if s contains a backquote.
+func rawQuote(s string) string {
+	if strings.Contains(s, "`") {
+		return strconv.Quote(s)
+	}
+	return "`" + s + "`"
+}
+
+// DefaultExpr validates the default value v for a field of the given type and returns it as Go expression. Numbers,
+// strings and bools are given as literals, time and uuid fields accept time.Now and uuid.New.
+func DefaultExpr(typ, v string) (string, error) {
+	var err error
+	switch typ {
+	case "int", "int8", "int16", "int32", "int64":
+		_, err = strconv.ParseInt(v, 0, bitSize(typ))
+	case "uint", "uint8", "uint16", "uint32", "uint64":
+		_, err = strconv.ParseUint(v, 0, bitSize(typ))
+	case "float", "float32":
+		_, err = strconv.ParseFloat(v, bitSize(typ))
+	case "bool":
+		var b bool
+		if b, err = strconv.ParseBool(v); err == nil {
+			v = strconv.FormatBool(b)
+		}
+	case "string", "text", "enum":
+		v = quote(v)
+	case "[]byte":
+		v = fmt.Sprintf("[]byte(%s)", quote(v))
+	case "time":
+		if v != "time.Now" && v != "now" {
+			return "", fmt.Errorf("the default of time fields must be time.Now")
+		}
+		v = "time.Now"
+	case "uuid":
+		if v != "uuid.New" && v != "new" {
+			return "", fmt.Errorf("the default of uuid fields must be uuid.New")
+		}
+		v = "uuid.New"
+	default:
+		return "", fmt.Errorf("wapiti cannot set the default of %s fields", typ)
+	}
+	if err != nil {
+		return "", fmt.Errorf("invalid default %q for %s field", v, typ)
+	}
+	return v, nil
+}
+
+// bitSize returns the size in bits of the numeric type. 0 for int and uint, 64 for float.
+func bitSize(typ string) int {
+	switch {
+	case typ == "float":
+		return 64
+	case strings.HasPrefix(typ, "float"):
+		return 32
+	}
+	n, _ := strconv.Atoi(strings.TrimLeft(typ, "uint"))
+	return n
+}
+
+// quote returns v as Go string literal. v is returned as is if it already is one.
+func quote(v string) string {
+	if _, err := strconv.Unquote(v); err == nil && strings.HasPrefix(v, `"`) {
+		return v
+	}
+	return strconv.Quote(v)
+}
+
+// validators lists the validators wapiti offers per field type.
+var validators = map[string][]string{
+	"string":  {"NotEmpty", "MinLen", "MaxLen", "Match"},
+	"text":    {"NotEmpty", "MinLen", "MaxLen", "Match"},
+	"int":     {"Positive", "Negative", "Min", "Max", "Range"},
+	"int8":    {"Positive", "Negative", "Min", "Max", "Range"},
+	"int16":   {"Positive", "Negative", "Min", "Max", "Range"},
+	"int32":   {"Positive", "Negative", "Min", "Max", "Range"},
+	"int64":   {"Positive", "Negative", "Min", "Max", "Range"},
+	"uint":    {"Positive", "Min", "Max", "Range"},
+	"uint8":   {"Positive", "Min", "Max", "Range"},
+	"uint16":  {"Positive", "Min", "Max", "Range"},
+	"uint32":  {"Positive", "Min", "Max", "Range"},
+	"uint64":  {"Positive", "Min", "Max", "Range"},
+	"float":   {"Positive", "Negative", "Min", "Max", "Range"},
+	"float32": {"Positive", "Negative", "Min", "Max", "Range"},
+}
+
+// NewValidator returns the validator with the given name for a field of the given type. arg holds the arguments of
+// the validator: a length for MinLen and MaxLen, a regular expression for Match, a number for Min and Max and two
+// numbers separated by space or comma for Range.
+func NewValidator(typ, name, arg string) (Validator, error) {
+	if !contains(validators[typ], name) {
+		return Validator{}, fmt.Errorf("%s fields have no validator %q", typ, name)
+	}
+	arg = strings.TrimSpace(arg)
+	v := Validator{Name: name}
+	switch name {
+	case "NotEmpty", "Positive", "Negative":
+		if arg != "" {
+			return Validator{}, fmt.Errorf("%s takes no arguments", name)
+		}
+	case "MinLen", "MaxLen":
+		if n, err := strconv.Atoi(arg); err != nil || n < 0 {
+			return Validator{}, fmt.Errorf("invalid length %q for %s", arg, name)
+		}
+		v.Args = arg
+	case "Match":
+		if _, err := regexp.Compile(arg); err != nil {
+			return Validator{}, fmt.Errorf("invalid regular expression for Match: %w", err)
+		}
+		v.Args = fmt.Sprintf("regexp.MustCompile(%s)", strconv.Quote(arg))
+	case "Min", "Max":
+		if _, err := DefaultExpr(typ, arg); err != nil {
+			return Validator{}, fmt.Errorf("invalid %s value %q for %s field", name, arg, typ)
+		}
+		v.Args = arg
+	case "Range":
+		ns := splitList(strings.ReplaceAll(arg, ",", " "))
+		if len(ns) != 2 {
+			return Validator{}, fmt.Errorf("Range needs a minimum and a maximum")
+		}
+		for _, n := range ns {
+			if _, err := DefaultExpr(typ, n); err != nil {
+				return Validator{}, fmt.Errorf("invalid Range value %q for %s field", n, typ)
+			}
+		}
+		v.Args = ns[0] + ", " + ns[1]
+	}
+	return v, nil
+}
+
+// enumCode returns the Values or NamedValues option of the enum field. The default value must be one of the values.
+func enumCode(f *Field) (string, error) {
+	if len(f.Enums) == 0 {
+		return "", fmt.Errorf("enum field %s has no values", f.Name)
+	}
+	var (
+		named bool
+		vs    []string
+		nvs   []string
+	)
+	for _, e := range f.Enums {
+		n := e.Name
+		if n == "" {
+			n = e.Value
+		} else {
+			named = true
+		}
+		vs = append(vs, e.Value)
+		nvs = append(nvs, n, e.Value)
+	}
+	if f.Default != "" {
+		d, err := strconv.Unquote(f.Default)
+		if err != nil || !contains(vs, d) {
+			return "", fmt.Errorf("default value %s of enum field %s is not one of its values", f.Default, f.Name)
 		}
-		file.Decls = append(file.Decls, expr.Decls...)
-		printer.Fprint(os.Stdout, w.fset, file)
+	}
+	if named {
+		return ".NamedValues(" + quoteList(nvs) + ")", nil
+	}
+	return ".Values(" + quoteList(vs) + ")", nil
+}
+
+// contains reports whether ss contains s.
+func contains(ss []string, s string) bool {
+	for _, v := range ss {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
+
+// lookupField looks for the field with the given name on the given schema. nil if no such field exists.
+func (w *Wapiti) lookupField(schema, name string) *load.Field {
+	if s := w.LookupNode(schema); s != nil {
+		for _, f := range s.Fields {
+			if f.Name == name {
+				return f
+			}
+		}
+	}
+	return nil
+}
+
+// code returns the ent builder chain for the edge, e.g. 'edge.From("owner", User.Type).Ref("pets").Unique()'.
+func (e *Edge) code() (string, error) {
+	if !fieldNameRgx.MatchString(e.Name) {
+		return "", errEdgeName
+	}
+	if e.Inverse && e.Ref == "" {
+		return "", errors.New("back-references must reference an edge")
+	}
+	var c string
+	if e.Inverse {
+		c = fmt.Sprintf("edge.From(%q, %s.Type).Ref(%q)", e.Name, e.Type, e.Ref)
 	} else {
-		fmt.Println("fields already exist")
+		c = fmt.Sprintf("edge.To(%q, %s.Type)", e.Name, e.Type)
 	}
-	return nil, nil
+	if e.Unique {
+		c += ".Unique()"
+	}
+	if e.Required {
+		c += ".Required()"
+	}
+	if e.Inverse && (e.Table != "" || len(e.Columns) > 0) {
+		return "", errors.New("the storage key is set on the edge a back-reference references")
+	}
+	var sk []string
+	if e.Table != "" {
+		sk = append(sk, fmt.Sprintf("edge.Table(%q)", e.Table))
+	}
+	switch len(e.Columns) {
+	case 0:
+	case 1:
+		sk = append(sk, fmt.Sprintf("edge.Column(%q)", e.Columns[0]))
+	case 2:
+		sk = append(sk, "edge.Columns("+quoteList(e.Columns)+")")
+	default:
+		return "", errors.New("edges have one foreign-key column or two for M2M edges")
+	}
+	if len(sk) > 0 {
+		c += ".StorageKey(" + strings.Join(sk, ", ") + ")"
+	}
+	return c, nil
 }
 
-// schemaNode extracts the ast.FuncDecl and the ast.File it was found in of the 'Fields()'-method for the load.Schema.
-func (w *Wapiti) fieldsMethod(s *load.Schema) (*ast.File, *ast.FuncDecl) {
+// code returns the ent builder chain for the index, e.g. 'index.Fields("name").Edges("owner").Unique()'.
+func (i *Index) code() (string, error) {
+	var c string
+	switch {
+	case len(i.Fields) > 0:
+		c = "index.Fields(" + quoteList(i.Fields) + ")"
+		if len(i.Edges) > 0 {
+			c += ".Edges(" + quoteList(i.Edges) + ")"
+		}
+	case len(i.Edges) > 0:
+		c = "index.Edges(" + quoteList(i.Edges) + ")"
+	default:
+		return "", errors.New("an index needs at least one field or edge")
+	}
+	if i.Unique {
+		c += ".Unique()"
+	}
+	if i.StorageKey != "" {
+		c += fmt.Sprintf(".StorageKey(%q)", i.StorageKey)
+	}
+	return c, nil
+}
+
+// quoteList returns the given strings as comma separated list of Go string literals.
+func quoteList(ss []string) string {
+	q := make([]string, len(ss))
+	for i, s := range ss {
+		q[i] = strconv.Quote(s)
+	}
+	return strings.Join(q, ", ")
+}
+
+// lookupEdge looks for the edge with the given name on the given schema. nil if no such edge exists.
+func (w *Wapiti) lookupEdge(schema, name string) *load.Edge {
+	if s := w.LookupNode(schema); s != nil {
+		for _, e := range s.Edges {
+			if e.Name == name {
+				return e
+			}
+		}
+	}
+	return nil
+}
+
+// source reads the given file from disk. Staged contents are returned instead if there are any.
+func (w *Wapiti) source(f *ast.File) (*source, error) {
+	name := w.fset.File(f.Pos()).Name()
+	if b, ok := w.staged[name]; ok {
+		return parseSource(name, b)
+	}
+	b, err := ioutil.ReadFile(name)
+	if err != nil {
+		return nil, fmt.Errorf("reading file %s: %w", name, err)
+	}
+	return parseSource(name, b)
+}
+
+// save fixes the imports of the source and writes it back to disk. Calls Reload afterwards. While changes are staged
+// the source is staged instead.
+func (w *Wapiti) save(s *source) error {
+	b, err := s.output()
+	if err != nil {
+		return err
+	}
+	if w.staged != nil {
+		return w.stageFile(s.name, b)
+	}
+	if err := w.write(s.name, b); err != nil {
+		return err
+	}
+	return w.Reload()
+}
+
+// output fixes the imports of the source and returns it formatted.
+func (s *source) output() ([]byte, error) {
+	pkgs := make(map[string]string, len(imports)+len(s.imports))
+	for n, p := range imports {
+		pkgs[n] = p
+	}
+	for n, p := range s.imports {
+		pkgs[n] = p
+	}
+	s.fixImports(pkgs)
+	return s.formatted()
+}
+
+// method extracts the ast.FuncDecl and the ast.File it was found in of the method with the given name for the
+// load.Schema.
+func (w *Wapiti) method(s *load.Schema, name string) (*ast.File, *ast.FuncDecl) {
 	var decl *ast.FuncDecl
 	var file *ast.File
 	for _, f := range w.ast.Files {
 		ast.Inspect(f, func(n ast.Node) bool {
 			if fn, ok := n.(*ast.FuncDecl); ok {
-				if fn.Name.Name == "Fields" && fn.Recv != nil && len(fn.Recv.List) == 1 {
+				if fn.Name.Name == name && fn.Recv != nil && len(fn.Recv.List) == 1 {
 					if r, ok := fn.Recv.List[0].Type.(*ast.Ident); ok && r.Name == s.Name {
 						file = f
 						decl = fn
@@ -120,7 +863,3 @@ func (w *Wapiti) file(s *load.Schema) *ast.File {
 	}
 	return nil
 }
-
-func (w *Wapiti) printNode(node interface{}) {
-	printer.Fprint(os.Stdout, w.fset, node)
-}
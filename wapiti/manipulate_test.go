@@ -0,0 +1,278 @@
+package wapiti
+
+import (
+	"entgo.io/ent/entc/load"
+	"github.com/masseelch/wapiti/wapiti/config"
+	"github.com/stretchr/testify/require"
+	"go/parser"
+	"go/token"
+	"io/ioutil"
+	"path/filepath"
+	"testing"
+)
+
+func TestFieldCode(t *testing.T) {
+	for _, tt := range []struct {
+		f    Field
+		want string
+	}{
+		{Field{Name: "name", Type: "string"}, `field.String("name")`},
+		{Field{Name: "age", Type: "uint8", Optional: true, Nillable: true}, `field.Uint8("age").Optional().Nillable()`},
+		{Field{Name: "id", Type: "uuid", Immutable: true}, `field.UUID("id", uuid.UUID{}).Immutable()`},
+	} {
+		c, err := tt.f.code()
+		require.NoError(t, err)
+		require.Equal(t, tt.want, c)
+	}
+	_, err := (&Field{Name: "x", Type: "unknown"}).code()
+	require.Error(t, err)
+}
+
+func TestEdgeCode(t *testing.T) {
+	c, err := (&Edge{Name: "pets", Type: "Pet"}).code()
+	require.NoError(t, err)
+	require.Equal(t, `edge.To("pets", Pet.Type)`, c)
+	c, err = (&Edge{Name: "owner", Type: "User", Inverse: true, Ref: "pets", Unique: true, Required: true}).code()
+	require.NoError(t, err)
+	require.Equal(t, `edge.From("owner", User.Type).Ref("pets").Unique().Required()`, c)
+	_, err = (&Edge{Name: "owner", Type: "User", Inverse: true}).code()
+	require.Error(t, err)
+	c, err = (&Edge{Name: "pets", Type: "Pet", Columns: []string{"owner_id"}}).code()
+	require.NoError(t, err)
+	require.Equal(t, `edge.To("pets", Pet.Type).StorageKey(edge.Column("owner_id"))`, c)
+	c, err = (&Edge{Name: "groups", Type: "Group", Table: "user_groups", Columns: []string{"user_id", "group_id"}}).code()
+	require.NoError(t, err)
+	require.Equal(t, `edge.To("groups", Group.Type).StorageKey(edge.Table("user_groups"), edge.Columns("user_id", "group_id"))`, c)
+	_, err = (&Edge{Name: "owner", Type: "User", Inverse: true, Ref: "pets", Columns: []string{"owner_id"}}).code()
+	require.Error(t, err)
+}
+
+func TestIndexCode(t *testing.T) {
+	c, err := (&Index{Fields: []string{"name", "age"}, Edges: []string{"owner"}, Unique: true, StorageKey: "idx"}).code()
+	require.NoError(t, err)
+	require.Equal(t, `index.Fields("name", "age").Edges("owner").Unique().StorageKey("idx")`, c)
+	c, err = (&Index{Edges: []string{"owner"}}).code()
+	require.NoError(t, err)
+	require.Equal(t, `index.Edges("owner")`, c)
+	_, err = (&Index{}).code()
+	require.Error(t, err)
+}
+
+func TestEnumCode(t *testing.T) {
+	es, err := ParseEnums([]string{"active", "inactive"})
+	require.NoError(t, err)
+	c, err := (&Field{Name: "status", Type: "enum", Enums: es, Default: `"active"`}).code()
+	require.NoError(t, err)
+	require.Equal(t, `field.Enum("status").Values("active", "inactive").Default("active")`, c)
+
+	es, err = ParseEnums([]string{"Active=ACTIVE", "inactive"})
+	require.NoError(t, err)
+	c, err = (&Field{Name: "status", Type: "enum", Enums: es}).code()
+	require.NoError(t, err)
+	require.Equal(t, `field.Enum("status").NamedValues("Active", "ACTIVE", "inactive", "inactive")`, c)
+
+	_, err = (&Field{Name: "status", Type: "enum", Enums: es, Default: `"unknown"`}).code()
+	require.Error(t, err)
+	_, err = (&Field{Name: "status", Type: "enum"}).code()
+	require.Error(t, err)
+
+	for _, vs := range [][]string{nil, {"in-progress"}, {"1st"}, {"a", "a"}, {"=a"}, {"A="}} {
+		_, err := ParseEnums(vs)
+		require.Error(t, err, vs)
+	}
+}
+
+func TestDefaultExpr(t *testing.T) {
+	for _, tt := range []struct {
+		typ, v, want string
+	}{
+		{"int", "42", "42"},
+		{"int8", "-128", "-128"},
+		{"uint16", "65535", "65535"},
+		{"float", "1.5", "1.5"},
+		{"bool", "1", "true"},
+		{"string", "hello", `"hello"`},
+		{"text", `"quoted"`, `"quoted"`},
+		{"[]byte", "abc", `[]byte("abc")`},
+		{"time", "now", "time.Now"},
+		{"uuid", "uuid.New", "uuid.New"},
+	} {
+		d, err := DefaultExpr(tt.typ, tt.v)
+		require.NoError(t, err)
+		require.Equal(t, tt.want, d)
+	}
+	for _, tt := range []struct{ typ, v string }{
+		{"int8", "128"}, {"uint", "-1"}, {"int", "1.5"}, {"float32", "x"}, {"bool", "maybe"}, {"time", "yesterday"}, {"json", "{}"},
+	} {
+		_, err := DefaultExpr(tt.typ, tt.v)
+		require.Error(t, err, tt)
+	}
+
+	c, err := (&Field{Name: "updated_at", Type: "time", Default: "time.Now", UpdateDefault: "time.Now", Immutable: true}).code()
+	require.NoError(t, err)
+	require.Equal(t, `field.Time("updated_at").Default(time.Now).UpdateDefault(time.Now).Immutable()`, c)
+	_, err = (&Field{Name: "age", Type: "int", UpdateDefault: "time.Now"}).code()
+	require.Error(t, err)
+}
+
+func TestNewValidator(t *testing.T) {
+	for _, tt := range []struct {
+		typ, name, arg, want string
+	}{
+		{"string", "NotEmpty", "", "NotEmpty()"},
+		{"text", "MaxLen", "255", "MaxLen(255)"},
+		{"string", "Match", `^[a-z]+\d*$`, `Match(regexp.MustCompile("^[a-z]+\\d*$"))`},
+		{"int", "Negative", "", "Negative()"},
+		{"uint8", "Max", "200", "Max(200)"},
+		{"float", "Range", "0.5, 1.5", "Range(0.5, 1.5)"},
+		{"int8", "Range", "-10 10", "Range(-10, 10)"},
+	} {
+		v, err := NewValidator(tt.typ, tt.name, tt.arg)
+		require.NoError(t, err)
+		require.Equal(t, tt.want, v.code())
+	}
+	for _, tt := range []struct{ typ, name, arg string }{
+		{"string", "Positive", ""},
+		{"uint", "Negative", ""},
+		{"bool", "NotEmpty", ""},
+		{"string", "NotEmpty", "1"},
+		{"string", "MinLen", "-1"},
+		{"string", "Match", "[a-z"},
+		{"uint8", "Max", "256"},
+		{"int", "Range", "1"},
+		{"int", "Range", "1 x"},
+	} {
+		_, err := NewValidator(tt.typ, tt.name, tt.arg)
+		require.Error(t, err, tt)
+	}
+
+	c, err := (&Field{Name: "email", Type: "string", Validators: []Validator{{Name: "NotEmpty"}, {Name: "MaxLen", Args: "255"}}, Optional: true}).code()
+	require.NoError(t, err)
+	require.Equal(t, `field.String("email").NotEmpty().MaxLen(255).Optional()`, c)
+}
+
+func TestFieldCodeAdvanced(t *testing.T) {
+	c, err := (&Field{
+		Name:       "email",
+		Type:       "string",
+		Unique:     true,
+		Sensitive:  true,
+		StorageKey: "mail",
+		StructTag:  `json:"email,omitempty"`,
+		SchemaType: map[string]string{"postgres": "citext", "mysql": "varchar(191)", "oracle": "varchar2(191)"},
+		Comment:    "Address for notifications.",
+	}).code()
+	require.NoError(t, err)
+	require.Equal(t, `field.String("email").Unique().Sensitive().StorageKey("mail").StructTag(`+"`"+`json:"email,omitempty"`+"`"+`).`+
+		`SchemaType(map[string]string{dialect.MySQL: "varchar(191)", dialect.Postgres: "citext", "oracle": "varchar2(191)"}).`+
+		`Comment("Address for notifications.")`, c)
+
+	for _, f := range []*Field{
+		{Name: "active", Type: "bool", Unique: true},
+		{Name: "age", Type: "int", Sensitive: true},
+		{Name: "active", Type: "bool", SchemaType: map[string]string{"mysql": "tinyint"}},
+		{Name: "tags", Type: "json", GoType: "[]string", Optional: true, Nillable: true},
+	} {
+		_, err := f.code()
+		require.Error(t, err, f)
+	}
+}
+
+func TestFieldCodeGoType(t *testing.T) {
+	for _, tt := range []struct {
+		f    *Field
+		want string
+	}{
+		{&Field{Name: "tags", Type: "json", GoType: "[]string"}, `field.JSON("tags", []string{})`},
+		{&Field{Name: "loc", Type: "other", GoType: "models.Point", SchemaType: map[string]string{"postgres": "point"}},
+			`field.Other("loc", &models.Point{}).SchemaType(map[string]string{dialect.Postgres: "point"})`},
+		{&Field{Name: "level", Type: "int", GoType: "models.Level", Optional: true}, `field.Int("level").GoType(models.Level(0)).Optional()`},
+		{&Field{Name: "id", Type: "uuid", GoType: "pgtype.UUID"}, `field.UUID("id", pgtype.UUID{})`},
+		{&Field{Name: "status", Type: "enum", GoType: "models.Status"}, `field.Enum("status").GoType(models.Status(""))`},
+	} {
+		c, err := tt.f.code()
+		require.NoError(t, err)
+		require.Equal(t, tt.want, c)
+	}
+	for _, f := range []*Field{
+		{Name: "tags", Type: "json"},
+		{Name: "loc", Type: "other", GoType: "models.Point"},
+	} {
+		_, err := f.code()
+		require.Error(t, err, f)
+	}
+}
+
+func TestIDField(t *testing.T) {
+	for typ, want := range map[string]string{
+		"uuid":   `field.UUID("id", uuid.UUID{}).Default(uuid.New)`,
+		"string": `field.String("id")`,
+		"int64":  `field.Int64("id")`,
+	} {
+		f, err := idField(typ, "")
+		require.NoError(t, err)
+		c, err := f.code()
+		require.NoError(t, err)
+		require.Equal(t, want, c)
+	}
+	f, err := idField("int", "id")
+	require.NoError(t, err)
+	require.Nil(t, f)
+	_, err = idField("float", "")
+	require.Error(t, err)
+
+	// The column of the ID field is kept.
+	for typ, want := range map[string]string{
+		"":      `field.Int("id").StorageKey("pet_id")`,
+		"int64": `field.Int64("id").StorageKey("pet_id")`,
+	} {
+		f, err := idField(typ, "pet_id")
+		require.NoError(t, err)
+		c, err := f.code()
+		require.NoError(t, err)
+		require.Equal(t, want, c)
+	}
+}
+
+func TestCreateSchemaFileExists(t *testing.T) {
+	dir := t.TempDir()
+	p := filepath.Join(dir, "user.go")
+	require.NoError(t, ioutil.WriteFile(p, []byte("package schema\n"), 0644))
+	w := &Wapiti{cfg: &config.Config{SchemaPath: dir}, spec: &load.SchemaSpec{}}
+	require.EqualError(t, w.CreateSchema("USer", ""), "file "+p+" already exists")
+	b, err := ioutil.ReadFile(p)
+	require.NoError(t, err)
+	require.Equal(t, "package schema\n", string(b))
+}
+
+func TestAddEdgeWithBackRef(t *testing.T) {
+	dir := t.TempDir()
+	p := filepath.Join(dir, "pet.go")
+	require.NoError(t, ioutil.WriteFile(p, []byte(`package schema
+
+import "entgo.io/ent"
+
+// Pet holds the schema definition for the Pet entity.
+type Pet struct {
+	ent.Schema
+}
+`), 0644))
+	fset := token.NewFileSet()
+	pkgs, err := parser.ParseDir(fset, dir, nil, parser.ParseComments)
+	require.NoError(t, err)
+	pet := &load.Schema{Name: "Pet"}
+	w := &Wapiti{
+		cfg:  &config.Config{SchemaPath: dir, DryRun: true},
+		fset: fset,
+		ast:  pkgs["schema"],
+		spec: &load.SchemaSpec{Schemas: []*load.Schema{pet}},
+	}
+	// Both edges end up in the same staged file.
+	require.ErrorIs(t, w.stage(func() error {
+		_, err := w.AddEdgeWithBackRef(&Edge{Schema: pet, Name: "children", Type: "Pet"}, "parent", true)
+		require.NoError(t, err)
+		require.Contains(t, string(w.staged[p]), `edge.To("children", Pet.Type),`)
+		require.Contains(t, string(w.staged[p]), `edge.From("parent", Pet.Type).Ref("children").Unique(),`)
+		return nil
+	}), ErrNotWritten)
+}
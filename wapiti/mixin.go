@@ -0,0 +1,281 @@
+package wapiti
+
+import (
+	"entgo.io/ent/entc/load"
+	"errors"
+	"fmt"
+	"github.com/c-bata/go-prompt"
+	"github.com/logrusorgru/aurora/v3"
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"golang.org/x/tools/go/packages"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"text/template"
+)
+
+var mixinTpl = template.Must(template.New("mixin").Parse(`
+// Mixin of the {{ . }}.
+func ({{ . }}) Mixin() []ent.Mixin {
+	return nil
+}
+`))
+
+// Mixin is a mixin to add to a schema.
+type Mixin struct {
+	Schema *load.Schema
+	// Code is the Go expression of the mixin, e.g. `mixin.Time{}`.
+	Code string
+	// Imports are the import paths of the packages Code references besides the well-known ones, keyed by package name.
+	Imports map[string]string
+}
+
+// MixinType is a mixin wapiti offers to add to schemas.
+type MixinType struct {
+	// Name is the qualified name of the mixin, e.g. mixin.Time.
+	Name string
+	Desc string
+	// Code is the Go expression of the mixin.
+	Code    string
+	Imports map[string]string
+}
+
+// builtinMixins are the mixins of ent's mixin package.
+var builtinMixins = []*MixinType{
+	{Name: "mixin.Time", Desc: "Adds the create_time and update_time fields", Code: "mixin.Time{}"},
+	{Name: "mixin.CreateTime", Desc: "Adds the create_time field", Code: "mixin.CreateTime{}"},
+	{Name: "mixin.UpdateTime", Desc: "Adds the update_time field", Code: "mixin.UpdateTime{}"},
+	{Name: "mixin.AnnotateFields", Desc: "Adds annotations to the fields of another mixin"},
+}
+
+// MixinTypes returns the mixins of ent's mixin package and all mixins declared in the module of the schema. A type is
+// considered a mixin if it embeds mixin.Schema.
+func (w *Wapiti) MixinTypes() ([]*MixinType, error) {
+	dir, err := filepath.Abs(w.cfg.SchemaPath)
+	if err != nil {
+		return nil, err
+	}
+	cfg := &packages.Config{Mode: packages.NeedName | packages.NeedFiles, Dir: moduleRoot(dir)}
+	ps, err := packages.Load(cfg, "./...")
+	if err != nil {
+		return nil, fmt.Errorf("loading packages: %w", err)
+	}
+	var ms []*MixinType
+	for _, p := range ps {
+		for _, n := range mixinsOf(p.GoFiles) {
+			m := &MixinType{Name: p.Name + "." + n, Desc: "Declared in " + p.PkgPath, Code: p.Name + "." + n + "{}"}
+			// Mixins of the schema package itself are referenced without qualifier.
+			if len(p.GoFiles) > 0 && filepath.Dir(p.GoFiles[0]) == dir {
+				m.Name, m.Code = n, n+"{}"
+			} else {
+				m.Imports = map[string]string{p.Name: p.PkgPath}
+			}
+			ms = append(ms, m)
+		}
+	}
+	sort.Slice(ms, func(i, j int) bool { return ms[i].Name < ms[j].Name })
+	return append(append([]*MixinType(nil), builtinMixins...), ms...), nil
+}
+
+// mixinsOf returns the names of the types declared in the files that embed mixin.Schema.
+func mixinsOf(files []string) []string {
+	var ns []string
+	fset := token.NewFileSet()
+	for _, name := range files {
+		f, err := parser.ParseFile(fset, name, nil, 0)
+		if err != nil {
+			continue
+		}
+		// The name the mixin package is imported by.
+		var pkg string
+		for _, i := range f.Imports {
+			if p, _ := strconv.Unquote(i.Path.Value); p == imports["mixin"] {
+				pkg = importName(i)
+			}
+		}
+		if pkg == "" {
+			continue
+		}
+		for _, d := range f.Decls {
+			g, ok := d.(*ast.GenDecl)
+			if !ok || g.Tok != token.TYPE {
+				continue
+			}
+			for _, s := range g.Specs {
+				ts := s.(*ast.TypeSpec)
+				if st, ok := ts.Type.(*ast.StructType); ok && embeds(st, pkg, "Schema") {
+					ns = append(ns, ts.Name.Name)
+				}
+			}
+		}
+	}
+	return ns
+}
+
+// embeds reports whether the struct embeds the type pkg.name.
+func embeds(st *ast.StructType, pkg, name string) bool {
+	for _, f := range st.Fields.List {
+		if len(f.Names) > 0 {
+			continue
+		}
+		if sel, ok := f.Type.(*ast.SelectorExpr); ok && sel.Sel.Name == name {
+			if x, ok := sel.X.(*ast.Ident); ok && x.Name == pkg {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// AddMixin adds the mixin to the Mixin method of the schema. Returns the fields the mixin contributes to the schema.
+// Calls Reload afterwards.
+func (w *Wapiti) AddMixin(m *Mixin) ([]*load.Field, error) {
+	if _, err := parser.ParseExpr(m.Code); err != nil {
+		return nil, fmt.Errorf("invalid mixin %q", m.Code)
+	}
+	ms, err := w.mixins(m.Schema)
+	if err != nil {
+		return nil, err
+	}
+	for _, c := range ms {
+		if c == m.Code {
+			return nil, fmt.Errorf("%s is already mixed into %s", m.Code, m.Schema.Name)
+		}
+	}
+	if err := w.appendToMethod(m.Schema, "Mixin", mixinTpl, "[]ent.Mixin", m.Code, m.Imports); err != nil {
+		return nil, err
+	}
+	return mixedInFields(w.LookupNode(m.Schema.Name), len(ms)), nil
+}
+
+// mixins returns the code of the mixins of the schema in the order they are listed in its Mixin method.
+func (w *Wapiti) mixins(s *load.Schema) ([]string, error) {
+	file, fn := w.method(s, "Mixin")
+	if fn == nil {
+		return nil, nil
+	}
+	src, err := w.source(file)
+	if err != nil {
+		return nil, err
+	}
+	ret, err := src.returned(src.method(s.Name, "Mixin"))
+	if err != nil {
+		return nil, err
+	}
+	lit, ok := ret.(*ast.CompositeLit)
+	if !ok {
+		return nil, nil
+	}
+	cs := make([]string, len(lit.Elts))
+	for i, e := range lit.Elts {
+		cs[i] = src.text(e)
+	}
+	return cs, nil
+}
+
+// mixedInFields returns the fields of the schema contributed by the mixin with the given index.
+func mixedInFields(s *load.Schema, i int) []*load.Field {
+	if s == nil {
+		return nil
+	}
+	var fs []*load.Field
+	for _, f := range s.Fields {
+		if f.Position != nil && f.Position.MixedIn && f.Position.MixinIndex == i {
+			fs = append(fs, f)
+		}
+	}
+	return fs
+}
+
+// NewMixin asks the user what mixin to add to the given node. The current mixins and the fields they contribute are
+// listed first. Returns nil, nil if the user wants to stop adding mixins.
+func (w *Wapiti) NewMixin(s *load.Schema) (*Mixin, []*load.Field, error) {
+	cur, err := w.mixins(s)
+	if err != nil {
+		return nil, nil, err
+	}
+	if len(cur) > 0 {
+		fmt.Printf("\nMixins of %s:\n", aurora.Cyan(s.Name))
+		for i, c := range cur {
+			fmt.Printf("  %s%s\n", c, describeMixedIn(mixedInFields(s, i)))
+		}
+	}
+	ts, err := w.MixinTypes()
+	if err != nil {
+		return nil, nil, err
+	}
+	sgst := make([]prompt.Suggest, len(ts))
+	for i, t := range ts {
+		sgst[i] = prompt.Suggest{Text: t.Name, Description: t.Desc}
+	}
+	n := ask(append(sgst, shortcuts...), "Mixin to add (press <return> to stop adding mixins):")
+	if n == "" {
+		return nil, nil, nil
+	}
+	if w.shortcut(n) {
+		return nil, nil, errShortcut
+	}
+	m := &Mixin{Schema: s}
+	if n == "mixin.AnnotateFields" {
+		if err := askAnnotateFields(m, ts); err != nil {
+			return nil, nil, err
+		}
+	} else {
+		t := mixinType(ts, n)
+		if t == nil {
+			return nil, nil, fmt.Errorf("unknown mixin %s", n)
+		}
+		m.Code, m.Imports = t.Code, t.Imports
+	}
+	fs, err := w.AddMixin(m)
+	if err != nil {
+		return nil, nil, err
+	}
+	return m, fs, nil
+}
+
+// askAnnotateFields asks for the mixin to annotate and the annotations to add to its fields.
+func askAnnotateFields(m *Mixin, ts []*MixinType) error {
+	var sgst []prompt.Suggest
+	for _, t := range ts {
+		if t.Code != "" {
+			sgst = append(sgst, prompt.Suggest{Text: t.Name, Description: t.Desc})
+		}
+	}
+	t := mixinType(ts, ask(sgst, "Mixin whose fields to annotate:"))
+	if t == nil || t.Code == "" {
+		return errors.New("unknown mixin")
+	}
+	a := ask(nil, "Annotations to add, e.g. entsql.Annotation{Size: 64} (separate multiple by comma):")
+	if _, err := parser.ParseExpr("f(" + a + ")"); err != nil || a == "" {
+		return fmt.Errorf("invalid annotations %q", a)
+	}
+	m.Code = fmt.Sprintf("mixin.AnnotateFields(%s, %s)", t.Code, a)
+	m.Imports = t.Imports
+	return nil
+}
+
+// mixinType returns the mixin with the given name. nil if there is no such mixin.
+func mixinType(ts []*MixinType, name string) *MixinType {
+	for _, t := range ts {
+		if t.Name == name {
+			return t
+		}
+	}
+	return nil
+}
+
+// describeMixedIn lists the names of the fields a mixin contributes.
+func describeMixedIn(fs []*load.Field) string {
+	if len(fs) == 0 {
+		return ""
+	}
+	ns := make([]string, len(fs))
+	for i, f := range fs {
+		ns[i] = f.Name
+	}
+	return " (" + strings.Join(ns, ", ") + ")"
+}
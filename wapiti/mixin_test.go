@@ -0,0 +1,43 @@
+package wapiti
+
+import (
+	"entgo.io/ent/entc/load"
+	"github.com/masseelch/wapiti/wapiti/config"
+	"github.com/stretchr/testify/require"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestMixinTypes(t *testing.T) {
+	dir := t.TempDir()
+	require.NoError(t, ioutil.WriteFile(filepath.Join(dir, "go.mod"), []byte("module example.com/app\n\ngo 1.16\n"), 0644))
+	for p, src := range map[string]string{
+		"ent/schema/base.go": "package schema\n\nimport \"entgo.io/ent/schema/mixin\"\n\ntype Base struct{ mixin.Schema }\n\ntype Pet struct{}\n",
+		"audit/audit.go":     "package audit\n\nimport m \"entgo.io/ent/schema/mixin\"\n\ntype Audit struct {\n\tm.Schema\n}\n\ntype other struct{ Schema m.Schema }\n",
+	} {
+		require.NoError(t, os.MkdirAll(filepath.Dir(filepath.Join(dir, p)), 0755))
+		require.NoError(t, ioutil.WriteFile(filepath.Join(dir, p), []byte(src), 0644))
+	}
+	w := &Wapiti{cfg: &config.Config{SchemaPath: filepath.Join(dir, "ent", "schema")}}
+	ts, err := w.MixinTypes()
+	require.NoError(t, err)
+	require.Equal(t, builtinMixins, ts[:len(builtinMixins)])
+	require.Equal(t, []*MixinType{
+		{Name: "Base", Desc: "Declared in example.com/app/ent/schema", Code: "Base{}"},
+		{Name: "audit.Audit", Desc: "Declared in example.com/app/audit", Code: "audit.Audit{}", Imports: map[string]string{"audit": "example.com/app/audit"}},
+	}, ts[len(builtinMixins):])
+}
+
+func TestMixedInFields(t *testing.T) {
+	s := &load.Schema{Fields: []*load.Field{
+		{Name: "create_time", Position: &load.Position{MixedIn: true, MixinIndex: 0}},
+		{Name: "update_time", Position: &load.Position{Index: 1, MixedIn: true, MixinIndex: 0}},
+		{Name: "owner_id", Position: &load.Position{MixedIn: true, MixinIndex: 1}},
+		{Name: "name", Position: &load.Position{}},
+	}}
+	require.Equal(t, " (create_time, update_time)", describeMixedIn(mixedInFields(s, 0)))
+	require.Equal(t, []*load.Field{s.Fields[2]}, mixedInFields(s, 1))
+	require.Empty(t, mixedInFields(s, 2))
+}
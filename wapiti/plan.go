@@ -0,0 +1,594 @@
+package wapiti
+
+import (
+	"entgo.io/ent/entc/load"
+	"entgo.io/ent/schema/edge"
+	"entgo.io/ent/schema/field"
+	"errors"
+	"fmt"
+	"gopkg.in/yaml.v3"
+	"io/ioutil"
+	"math"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// SpecFile describes ent schemas declaratively. It can be written in YAML or JSON.
+type SpecFile struct {
+	Schemas []*SpecSchema `yaml:"schemas"`
+}
+
+// SpecSchema describes a single schema of a SpecFile.
+type SpecSchema struct {
+	Name string `yaml:"name"`
+	// ID is the type of the ID field of a new schema: int (default), uuid, string or int64.
+	ID string `yaml:"id"`
+	// IDStorageKey is the name of the column of the ID field of a new schema if it is not id.
+	IDStorageKey string `yaml:"id_storage_key"`
+	// Table is the name of the table if it differs from the one ent derives from the schema name.
+	Table   string       `yaml:"table"`
+	Fields  []*SpecField `yaml:"fields"`
+	Edges   []*SpecEdge  `yaml:"edges"`
+	Indexes []*SpecIndex `yaml:"indexes"`
+}
+
+// SpecField describes a field of a SpecSchema. Type is one of the types offered by the wizard and defaults to string.
+type SpecField struct {
+	Name string `yaml:"name"`
+	Type string `yaml:"type"`
+	// Values of an enum field. Named values are given as name=value.
+	Values []string `yaml:"values"`
+	// Default is the default value, e.g. 1, "text", true, time.Now or uuid.New.
+	Default string `yaml:"default"`
+	// UpdateDefault sets time fields to the current time on updates.
+	UpdateDefault bool `yaml:"update_default"`
+	Optional      bool `yaml:"optional"`
+	Nillable      bool `yaml:"nillable"`
+	Immutable     bool `yaml:"immutable"`
+	Unique        bool `yaml:"unique"`
+	Sensitive     bool `yaml:"sensitive"`
+	// MaxLen is the maximum length of string fields, also used as size of the column.
+	MaxLen int `yaml:"max_len"`
+	// StorageKey is the name of the column.
+	StorageKey string `yaml:"storage_key"`
+	// StructTag is the struct tag of the field in the generated entity.
+	StructTag string `yaml:"struct_tag"`
+	// SchemaType maps dialect names like mysql to the database type of the column.
+	SchemaType map[string]string `yaml:"schema_type"`
+	Comment    string            `yaml:"comment"`
+	// GoType is the custom Go type of the field, e.g. *models.Point. Required for json and other fields.
+	GoType string `yaml:"go_type"`
+}
+
+// SpecEdge describes an edge of a SpecSchema. If Ref is set the edge is a back-reference (edge.From).
+type SpecEdge struct {
+	Name     string `yaml:"name"`
+	Type     string `yaml:"type"`
+	Ref      string `yaml:"ref"`
+	Unique   bool   `yaml:"unique"`
+	Required bool   `yaml:"required"`
+	// Table is the join table of a M2M edge and Columns are the foreign-key columns of the edge. Both can only be set
+	// on edges that are not back-references.
+	Table   string   `yaml:"table"`
+	Columns []string `yaml:"columns"`
+}
+
+// SpecIndex describes an index of a SpecSchema.
+type SpecIndex struct {
+	Fields     []string `yaml:"fields"`
+	Edges      []string `yaml:"edges"`
+	Unique     bool     `yaml:"unique"`
+	StorageKey string   `yaml:"storage_key"`
+}
+
+// Change is a single modification needed to reconcile the schema files with a SpecFile. Changes without an apply
+// function are conflicts wapiti cannot resolve and are only reported.
+type Change struct {
+	// Op is "+" for additions, "~" for updates and "!" for conflicts.
+	Op    string
+	Desc  string
+	apply func(w *Wapiti) error
+}
+
+// Conflict reports whether the change cannot be applied.
+func (c *Change) Conflict() bool {
+	return c.apply == nil
+}
+
+// String implements fmt.Stringer.
+func (c *Change) String() string {
+	return c.Op + " " + c.Desc
+}
+
+// LoadSpecFile reads and parses the SpecFile at the given path.
+func LoadSpecFile(path string) (*SpecFile, error) {
+	b, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("reading file %s: %w", path, err)
+	}
+	sf := new(SpecFile)
+	if err := yaml.Unmarshal(b, sf); err != nil {
+		return nil, fmt.Errorf("parsing file %s: %w", path, err)
+	}
+	for _, s := range sf.Schemas {
+		for _, f := range s.Fields {
+			if f.Type == "" {
+				f.Type = defaultType
+			}
+		}
+	}
+	return sf, nil
+}
+
+// Plan compares the SpecFile with the loaded schemas and returns the changes needed to reconcile them. Schemas are
+// created first, then fields, edges and indexes are added, so that edges and indexes can reference them.
+func (w *Wapiti) Plan(sf *SpecFile) ([]*Change, error) {
+	var schemas, fields, edges, indexes []*Change
+	for _, ss := range sf.Schemas {
+		ss := ss
+		if !nodeNameRgx.MatchString(ss.Name) {
+			return nil, fmt.Errorf("%s: %w", ss.Name, errSchemaName)
+		}
+		s := w.LookupNode(ss.Name)
+		if s == nil {
+			if _, err := idField(ss.ID, ss.IDStorageKey); err != nil {
+				return nil, fmt.Errorf("%s: %w", ss.Name, err)
+			}
+			desc := fmt.Sprintf("create schema %s", ss.Name)
+			switch {
+			case ss.IDStorageKey != "":
+				desc += fmt.Sprintf(" (%s id in column %s)", orDefault(ss.ID, "int"), ss.IDStorageKey)
+			case ss.ID != "":
+				desc += fmt.Sprintf(" (%s id)", ss.ID)
+			}
+			s = &load.Schema{Name: ss.Name}
+			schemas = append(schemas, &Change{
+				Op:    "+",
+				Desc:  desc,
+				apply: func(w *Wapiti) error { return w.createSchema(ss.Name, ss.ID, ss.IDStorageKey) },
+			})
+		}
+		if c, err := planTable(s, ss); err != nil {
+			return nil, err
+		} else if c != nil {
+			schemas = append(schemas, c)
+		}
+		for _, sf := range ss.Fields {
+			c, err := w.planField(s, sf)
+			if err != nil {
+				return nil, err
+			}
+			if c != nil {
+				fields = append(fields, c)
+			}
+		}
+		for _, se := range ss.Edges {
+			if c := planEdge(s, se); c != nil {
+				edges = append(edges, c)
+			}
+		}
+		for _, si := range ss.Indexes {
+			if c := planIndex(s, si); c != nil {
+				indexes = append(indexes, c)
+			}
+		}
+	}
+	return append(append(append(schemas, fields...), edges...), indexes...), nil
+}
+
+// CheckOverwrites returns an error if applying the SpecFile would change existing schemas and merge is not set, or
+// if the file of a new schema already exists.
+func (w *Wapiti) CheckOverwrites(sf *SpecFile, merge bool) error {
+	var exist []string
+	for _, ss := range sf.Schemas {
+		if w.LookupNode(ss.Name) != nil {
+			if !merge {
+				exist = append(exist, ss.Name)
+			}
+			continue
+		}
+		f := w.schemaFile(ss.Name)
+		if _, err := os.Stat(f); err == nil {
+			return fmt.Errorf("file %s of new schema %s already exists", f, ss.Name)
+		}
+	}
+	if len(exist) > 0 {
+		return fmt.Errorf("schemas %s already exist: merge them to reconcile them with the import", strings.Join(exist, ", "))
+	}
+	return nil
+}
+
+// Apply applies the given changes in order. Conflicts are skipped. The changes are staged and the files are written
+// once all changes were applied, so that changes can build on each other even if the files are not written.
+func (w *Wapiti) Apply(cs []*Change) error {
+	err := w.stage(func() error {
+		for _, c := range cs {
+			if c.Conflict() {
+				continue
+			}
+			if err := c.apply(w); err != nil {
+				return fmt.Errorf("%s: %w", c.Desc, err)
+			}
+		}
+		return nil
+	})
+	if errors.Is(err, ErrNotWritten) {
+		return nil
+	}
+	return err
+}
+
+// planField returns the change needed for the field or nil if the schema already has the field.
+func (w *Wapiti) planField(s *load.Schema, sf *SpecField) (*Change, error) {
+	if _, ok := builders[sf.Type]; !ok {
+		return nil, fmt.Errorf("%s.%s: unknown field type %q", s.Name, sf.Name, sf.Type)
+	}
+	for _, lf := range s.Fields {
+		if lf.Name != sf.Name {
+			continue
+		}
+		// The default and Go type are only known by the source.
+		cur, err := w.FieldOf(s, sf.Name)
+		if err != nil {
+			return nil, err
+		}
+		var def string
+		if sf.Default != "" {
+			if def, err = DefaultExpr(sf.Type, sf.Default); err != nil {
+				return nil, fmt.Errorf("%s.%s: %w", s.Name, sf.Name, err)
+			}
+		}
+		var diff []string
+		if t := typeName(lf); t != sf.Type {
+			diff = append(diff, fmt.Sprintf("type %s != %s", t, sf.Type))
+		}
+		if sf.Type == "enum" && !equalStrings(enumValues(lf), sf.Values) {
+			diff = append(diff, fmt.Sprintf("values [%s] != [%s]", strings.Join(enumValues(lf), " "), strings.Join(sf.Values, " ")))
+		}
+		diff = appendStringDiff(diff, "default", cur.Default, def)
+		diff = appendDiff(diff, "update_default", lf.UpdateDefault, sf.UpdateDefault)
+		diff = appendDiff(diff, "optional", lf.Optional, sf.Optional)
+		diff = appendDiff(diff, "nillable", lf.Nillable, sf.Nillable)
+		diff = appendDiff(diff, "immutable", lf.Immutable, sf.Immutable)
+		diff = appendDiff(diff, "unique", lf.Unique, sf.Unique)
+		diff = appendDiff(diff, "sensitive", lf.Sensitive, sf.Sensitive)
+		if sf.MaxLen != 0 && (lf.Size == nil || *lf.Size != int64(sf.MaxLen)) {
+			diff = append(diff, fmt.Sprintf("max_len %d", sf.MaxLen))
+		}
+		diff = appendStringDiff(diff, "storage_key", lf.StorageKey, sf.StorageKey)
+		diff = appendStringDiff(diff, "struct_tag", lf.Tag, sf.StructTag)
+		diff = appendStringDiff(diff, "comment", lf.Comment, sf.Comment)
+		if !equalMaps(lf.SchemaType, sf.SchemaType) {
+			diff = append(diff, "schema_type")
+		}
+		if sf.GoType != "" {
+			diff = appendStringDiff(diff, "go_type", cur.GoType, goTypeName(sf.GoType))
+		}
+		if len(diff) == 0 {
+			return nil, nil
+		}
+		name := s.Name
+		return &Change{
+			Op:   "~",
+			Desc: fmt.Sprintf("update field %s of %s (%s)", sf.Name, name, strings.Join(diff, ", ")),
+			apply: func(w *Wapiti) error {
+				s, err := w.lookup(name)
+				if err != nil {
+					return err
+				}
+				f, err := sf.update(w, s)
+				if err != nil {
+					return err
+				}
+				_, err = w.UpdateField(s, sf.Name, f)
+				return err
+			},
+		}, nil
+	}
+	name := s.Name
+	return &Change{
+		Op:   "+",
+		Desc: fmt.Sprintf("add field %s (%s) to %s", sf.Name, sf.Type, name),
+		apply: func(w *Wapiti) error {
+			s, err := w.lookup(name)
+			if err != nil {
+				return err
+			}
+			f, err := sf.field(w, s)
+			if err != nil {
+				return err
+			}
+			_, err = w.AddField(f)
+			return err
+		},
+	}, nil
+}
+
+// field returns the Field described by the SpecField.
+func (sf *SpecField) field(w *Wapiti, s *load.Schema) (*Field, error) {
+	f := &Field{
+		Schema:     s,
+		Name:       sf.Name,
+		Type:       sf.Type,
+		Optional:   sf.Optional,
+		Nillable:   sf.Nillable,
+		Immutable:  sf.Immutable,
+		Unique:     sf.Unique,
+		Sensitive:  sf.Sensitive,
+		StorageKey: sf.StorageKey,
+		StructTag:  sf.StructTag,
+		SchemaType: sf.SchemaType,
+		Comment:    sf.Comment,
+	}
+	if len(sf.Values) > 0 {
+		es, err := ParseEnums(sf.Values)
+		if err != nil {
+			return nil, err
+		}
+		f.Enums = es
+	}
+	if sf.Default != "" {
+		d, err := DefaultExpr(sf.Type, sf.Default)
+		if err != nil {
+			return nil, err
+		}
+		f.Default = d
+	}
+	if sf.UpdateDefault {
+		f.UpdateDefault = "time.Now"
+	}
+	if sf.MaxLen != 0 {
+		f.Validators = append(f.Validators, Validator{Name: "MaxLen", Args: strconv.Itoa(sf.MaxLen)})
+	}
+	if sf.GoType != "" {
+		t, pkgs, err := w.ResolveGoType(sf.GoType)
+		if err != nil {
+			return nil, err
+		}
+		f.GoType, f.Imports = t, pkgs
+	}
+	return f, nil
+}
+
+// update returns the Field replacing the existing field of the schema described by the SpecField. Since UpdateField
+// replaces the validators and the Go type, the ones of the existing field the SpecField does not set are kept unless
+// the type of the field changes.
+func (sf *SpecField) update(w *Wapiti, s *load.Schema) (*Field, error) {
+	f, err := sf.field(w, s)
+	if err != nil {
+		return nil, err
+	}
+	cur, err := w.FieldOf(s, sf.Name)
+	if err != nil {
+		return nil, err
+	}
+	if cur.Type != f.Type {
+		return f, nil
+	}
+	if f.GoType == "" {
+		f.GoType = cur.GoType
+	}
+	for _, v := range cur.Validators {
+		if !hasValidator(f.Validators, v.Name) {
+			f.Validators = append(f.Validators, v)
+		}
+	}
+	return f, nil
+}
+
+// hasValidator reports whether vs contains a validator with the given name.
+func hasValidator(vs []Validator, name string) bool {
+	for _, v := range vs {
+		if v.Name == name {
+			return true
+		}
+	}
+	return false
+}
+
+// enumValues returns the values of the loaded enum field in the format of SpecField.Values.
+func enumValues(f *load.Field) []string {
+	vs := make([]string, len(f.Enums))
+	for i, e := range f.Enums {
+		if e.N == e.V {
+			vs[i] = e.V
+		} else {
+			vs[i] = e.N + "=" + e.V
+		}
+	}
+	return vs
+}
+
+// planEdge returns the change needed for the edge or nil if the schema already has the edge.
+func planEdge(s *load.Schema, se *SpecEdge) *Change {
+	for _, le := range s.Edges {
+		if le.Name != se.Name {
+			continue
+		}
+		var diff []string
+		if le.Type != se.Type {
+			diff = append(diff, fmt.Sprintf("type %s != %s", le.Type, se.Type))
+		}
+		if le.RefName != se.Ref {
+			diff = append(diff, fmt.Sprintf("ref %q != %q", le.RefName, se.Ref))
+		}
+		diff = appendDiff(diff, "unique", le.Unique, se.Unique)
+		diff = appendDiff(diff, "required", le.Required, se.Required)
+		if se.Table != "" || len(se.Columns) > 0 {
+			var sk edge.StorageKey
+			if le.StorageKey != nil {
+				sk = *le.StorageKey
+			}
+			diff = appendStringDiff(diff, "table", sk.Table, se.Table)
+			if !equalStrings(sk.Columns, se.Columns) {
+				diff = append(diff, fmt.Sprintf("columns [%s] != [%s]", strings.Join(sk.Columns, " "), strings.Join(se.Columns, " ")))
+			}
+		}
+		if len(diff) == 0 {
+			return nil
+		}
+		return &Change{Op: "!", Desc: fmt.Sprintf("edge %s of %s differs (%s)", se.Name, s.Name, strings.Join(diff, ", "))}
+	}
+	name := s.Name
+	return &Change{
+		Op:   "+",
+		Desc: fmt.Sprintf("add edge %s (%s) to %s", se.Name, se.Type, name),
+		apply: func(w *Wapiti) error {
+			s, err := w.lookup(name)
+			if err != nil {
+				return err
+			}
+			_, err = w.AddEdge(&Edge{
+				Schema:   s,
+				Name:     se.Name,
+				Type:     se.Type,
+				Inverse:  se.Ref != "",
+				Ref:      se.Ref,
+				Unique:   se.Unique,
+				Required: se.Required,
+				Table:    se.Table,
+				Columns:  se.Columns,
+			})
+			return err
+		},
+	}
+}
+
+// planTable returns the change needed to set the table name of the schema or nil if it is already set.
+func planTable(s *load.Schema, ss *SpecSchema) (*Change, error) {
+	if ss.Table == "" {
+		return nil, nil
+	}
+	a, err := SQLAnnotation(s.Annotations)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", s.Name, err)
+	}
+	if a.Table == ss.Table {
+		return nil, nil
+	}
+	name := s.Name
+	return &Change{
+		Op:   "~",
+		Desc: fmt.Sprintf("set table of %s to %s", name, ss.Table),
+		apply: func(w *Wapiti) error {
+			s, err := w.lookup(name)
+			if err != nil {
+				return err
+			}
+			a, err := SQLAnnotation(s.Annotations)
+			if err != nil {
+				return err
+			}
+			a.Table = ss.Table
+			return w.AnnotateSchema(s, a)
+		},
+	}, nil
+}
+
+// planIndex returns the change needed for the index or nil if the schema already has the index.
+func planIndex(s *load.Schema, si *SpecIndex) *Change {
+	for _, li := range s.Indexes {
+		if equalStrings(li.Fields, si.Fields) && equalStrings(li.Edges, si.Edges) {
+			var diff []string
+			diff = appendDiff(diff, "unique", li.Unique, si.Unique)
+			if li.StorageKey != si.StorageKey {
+				diff = append(diff, fmt.Sprintf("storage_key %q != %q", li.StorageKey, si.StorageKey))
+			}
+			if len(diff) == 0 {
+				return nil
+			}
+			return &Change{Op: "!", Desc: fmt.Sprintf("index on %s of %s differs (%s)", indexColumns(si), s.Name, strings.Join(diff, ", "))}
+		}
+	}
+	name := s.Name
+	return &Change{
+		Op:   "+",
+		Desc: fmt.Sprintf("add index on %s to %s", indexColumns(si), name),
+		apply: func(w *Wapiti) error {
+			s, err := w.lookup(name)
+			if err != nil {
+				return err
+			}
+			_, err = w.AddIndex(&Index{
+				Schema:     s,
+				Fields:     si.Fields,
+				Edges:      si.Edges,
+				Unique:     si.Unique,
+				StorageKey: si.StorageKey,
+			})
+			return err
+		},
+	}
+}
+
+// typeName returns the wizard type of the loaded field.
+func typeName(f *load.Field) string {
+	switch t := f.Info.Type; t {
+	case field.TypeString:
+		if f.Size != nil && *f.Size == math.MaxInt32 {
+			return "text"
+		}
+		return "string"
+	case field.TypeFloat64:
+		return "float"
+	case field.TypeTime:
+		return "time"
+	case field.TypeUUID:
+		return "uuid"
+	case field.TypeJSON:
+		return "json"
+	case field.TypeEnum:
+		return "enum"
+	default:
+		// The remaining types (int, uint8, bool, []byte, enum, other, ...) print as the wizard type.
+		return t.String()
+	}
+}
+
+// appendDiff appends a description of the difference of the given option to diff if have and want differ.
+func appendDiff(diff []string, opt string, have, want bool) []string {
+	if have != want {
+		diff = append(diff, fmt.Sprintf("%s %t != %t", opt, have, want))
+	}
+	return diff
+}
+
+// appendStringDiff appends a description of the difference to diff if have and want differ.
+func appendStringDiff(diff []string, opt, have, want string) []string {
+	if have != want {
+		diff = append(diff, fmt.Sprintf("%s %q != %q", opt, have, want))
+	}
+	return diff
+}
+
+// indexColumns describes the fields and edges of the index.
+func indexColumns(si *SpecIndex) string {
+	return strings.Join(append(append([]string(nil), si.Fields...), si.Edges...), ", ")
+}
+
+// equalStrings reports whether a and b contain the same strings in the same order.
+func equalStrings(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// equalMaps reports whether a and b contain the same keys and values.
+func equalMaps(a, b map[string]string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for k, v := range a {
+		if w, ok := b[k]; !ok || v != w {
+			return false
+		}
+	}
+	return true
+}
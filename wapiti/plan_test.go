@@ -0,0 +1,199 @@
+package wapiti
+
+import (
+	"entgo.io/ent/entc/load"
+	"entgo.io/ent/schema/field"
+	"github.com/masseelch/wapiti/wapiti/config"
+	"github.com/stretchr/testify/require"
+	"go/parser"
+	"go/token"
+	"io/ioutil"
+	"path/filepath"
+	"testing"
+)
+
+func TestPlan(t *testing.T) {
+	p := filepath.Join(t.TempDir(), "spec.yaml")
+	require.NoError(t, ioutil.WriteFile(p, []byte(`
+schemas:
+  - name: Pet
+    fields:
+      - name: name
+        max_len: 32
+      - name: age
+        type: int
+        optional: true
+        default: 2
+        go_type: example.com/app/models.Age
+    edges:
+      - name: owner
+        type: User
+        ref: pets
+        unique: true
+  - name: User
+    id_storage_key: user_id
+    fields:
+      - name: name
+    edges:
+      - name: pets
+        type: Pet
+    indexes:
+      - fields: [name]
+        unique: true
+`), 0644))
+	sf, err := LoadSpecFile(p)
+	require.NoError(t, err)
+	dir := t.TempDir()
+	require.NoError(t, ioutil.WriteFile(filepath.Join(dir, "pet.go"), []byte(`package schema
+
+// Pet holds the schema definition for the Pet entity.
+type Pet struct {
+	ent.Schema
+}
+
+// Fields of the Pet.
+func (Pet) Fields() []ent.Field {
+	return []ent.Field{
+		field.String("name").NotEmpty(),
+		field.Int("age").GoType(models.Age(0)).Default(1).Positive(),
+	}
+}
+`), 0644))
+	fset := token.NewFileSet()
+	pkgs, err := parser.ParseDir(fset, dir, nil, parser.ParseComments)
+	require.NoError(t, err)
+	w := &Wapiti{
+		cfg:  &config.Config{SchemaPath: dir},
+		fset: fset,
+		ast:  pkgs["schema"],
+		spec: &load.SchemaSpec{Schemas: []*load.Schema{{
+			Name: "Pet",
+			Fields: []*load.Field{
+				{Name: "name", Info: &field.TypeInfo{Type: field.TypeString}},
+				{Name: "age", Info: &field.TypeInfo{Type: field.TypeInt, Ident: "models.Age"}, Default: true},
+			},
+		}}},
+	}
+	cs, err := w.Plan(sf)
+	require.NoError(t, err)
+	var plan []string
+	for _, c := range cs {
+		plan = append(plan, c.String())
+	}
+	require.Equal(t, []string{
+		"+ create schema User (int id in column user_id)",
+		"~ update field name of Pet (max_len 32)",
+		"~ update field age of Pet (default \"1\" != \"2\", optional false != true)",
+		"+ add field name (string) to User",
+		"+ add edge owner (User) to Pet",
+		"+ add edge pets (Pet) to User",
+		"+ add index on name to User",
+	}, plan)
+}
+
+func TestSpecFieldUpdate(t *testing.T) {
+	dir := t.TempDir()
+	require.NoError(t, ioutil.WriteFile(filepath.Join(dir, "pet.go"), []byte(`package schema
+
+// Fields of the Pet.
+func (Pet) Fields() []ent.Field {
+	return []ent.Field{
+		field.String("name").NotEmpty().MaxLen(10),
+		field.Int("age").GoType(models.Age(0)).Positive(),
+	}
+}
+`), 0644))
+	fset := token.NewFileSet()
+	pkgs, err := parser.ParseDir(fset, dir, nil, parser.ParseComments)
+	require.NoError(t, err)
+	s := &load.Schema{
+		Name: "Pet",
+		Fields: []*load.Field{
+			{Name: "name", Info: &field.TypeInfo{Type: field.TypeString}},
+			{Name: "age", Info: &field.TypeInfo{Type: field.TypeInt, Ident: "models.Age"}},
+		},
+	}
+	w := &Wapiti{cfg: &config.Config{SchemaPath: dir}, fset: fset, ast: pkgs["schema"], spec: &load.SchemaSpec{Schemas: []*load.Schema{s}}}
+
+	// Validators and the Go type the spec does not set are kept.
+	f, err := (&SpecField{Name: "name", Type: "string", Optional: true, MaxLen: 32}).update(w, s)
+	require.NoError(t, err)
+	require.Equal(t, []Validator{{Name: "MaxLen", Args: "32"}, {Name: "NotEmpty"}}, f.Validators)
+	f, err = (&SpecField{Name: "age", Type: "int", Optional: true}).update(w, s)
+	require.NoError(t, err)
+	require.Equal(t, "models.Age", f.GoType)
+	require.Equal(t, []Validator{{Name: "Positive"}}, f.Validators)
+
+	// They are dropped if the type changes.
+	f, err = (&SpecField{Name: "age", Type: "string"}).update(w, s)
+	require.NoError(t, err)
+	require.Empty(t, f.GoType)
+	require.Empty(t, f.Validators)
+}
+
+func TestCheckOverwrites(t *testing.T) {
+	dir := t.TempDir()
+	require.NoError(t, ioutil.WriteFile(filepath.Join(dir, "group.go"), []byte("package schema\n"), 0644))
+	w := &Wapiti{
+		cfg:  &config.Config{SchemaPath: dir},
+		spec: &load.SchemaSpec{Schemas: []*load.Schema{{Name: "User"}}},
+	}
+	require.NoError(t, w.CheckOverwrites(&SpecFile{Schemas: []*SpecSchema{{Name: "Pet"}}}, false))
+	sf := &SpecFile{Schemas: []*SpecSchema{{Name: "Pet"}, {Name: "User"}}}
+	require.EqualError(t, w.CheckOverwrites(sf, false), "schemas User already exist: merge them to reconcile them with the import")
+	require.NoError(t, w.CheckOverwrites(sf, true))
+	sf.Schemas = append(sf.Schemas, &SpecSchema{Name: "Group"})
+	require.EqualError(t, w.CheckOverwrites(sf, true), "file "+filepath.Join(dir, "group.go")+" of new schema Group already exists")
+}
+
+func TestApplyDryRun(t *testing.T) {
+	dir := t.TempDir()
+	pet := []byte(`package schema
+
+import "entgo.io/ent"
+
+// Pet holds the schema definition for the Pet entity.
+type Pet struct {
+	ent.Schema
+}
+
+// Fields of the Pet.
+func (Pet) Fields() []ent.Field {
+	return nil
+}
+
+// Edges of the Pet.
+func (Pet) Edges() []ent.Edge {
+	return nil
+}
+`)
+	require.NoError(t, ioutil.WriteFile(filepath.Join(dir, "pet.go"), pet, 0644))
+	fset := token.NewFileSet()
+	pkgs, err := parser.ParseDir(fset, dir, nil, parser.ParseComments)
+	require.NoError(t, err)
+	w := &Wapiti{
+		cfg:  &config.Config{SchemaPath: dir, DryRun: true},
+		fset: fset,
+		ast:  pkgs["schema"],
+		spec: &load.SchemaSpec{Schemas: []*load.Schema{{Name: "Pet"}}},
+	}
+	cs, err := w.Plan(&SpecFile{Schemas: []*SpecSchema{
+		{
+			Name:   "User",
+			Fields: []*SpecField{{Name: "name", Type: "string"}},
+			Edges:  []*SpecEdge{{Name: "pets", Type: "Pet"}},
+		},
+		{Name: "Pet", Fields: []*SpecField{{Name: "age", Type: "int"}}},
+	}})
+	require.NoError(t, err)
+	require.Len(t, cs, 4)
+
+	// The changes of the new schema are previewed, nothing is written.
+	require.NoError(t, w.Apply(cs))
+	require.NoFileExists(t, filepath.Join(dir, "user.go"))
+	b, err := ioutil.ReadFile(filepath.Join(dir, "pet.go"))
+	require.NoError(t, err)
+	require.Equal(t, pet, b)
+	require.Len(t, w.spec.Schemas, 1)
+	require.Len(t, w.ast.Files, 1)
+}
@@ -0,0 +1,323 @@
+package wapiti
+
+import (
+	"bytes"
+	"entgo.io/ent/entc/load"
+	"fmt"
+	"github.com/c-bata/go-prompt"
+	"io/ioutil"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"text/template"
+)
+
+var policyTpl = template.Must(template.New("policy").Parse(`
+// Policy defines the privacy policy of the {{ .Schema }}.
+func ({{ .Schema }}) Policy() ent.Policy {
+	return privacy.Policy{
+		Mutation: privacy.MutationPolicy{
+			{{- range .Mutation }}
+			{{ . }},
+			{{- end }}
+		},
+		Query: privacy.QueryPolicy{
+			{{- range .Query }}
+			{{ . }},
+			{{- end }}
+		},
+	}
+}
+`))
+
+var rulePkgTpl = template.Must(template.New("rule").Parse(`// Package rule holds the privacy rules used by the policies of the schemas.
+package rule
+
+import (
+	"context"
+	"{{ .Gen }}/privacy"
+)
+
+// Viewer is the user queries and mutations are executed on behalf of.
+type Viewer struct {
+	ID {{ .ID }}
+}
+
+type viewerKey struct{}
+
+// NewContext returns a copy of parent carrying the viewer.
+func NewContext(parent context.Context, v *Viewer) context.Context {
+	return context.WithValue(parent, viewerKey{}, v)
+}
+
+// FromContext returns the viewer carried by the context. nil if there is none.
+func FromContext(ctx context.Context) *Viewer {
+	v, _ := ctx.Value(viewerKey{}).(*Viewer)
+	return v
+}
+
+// DenyIfNoViewer is a rule that denies the operation if there is no viewer in the context.
+func DenyIfNoViewer() privacy.QueryMutationRule {
+	return privacy.ContextQueryMutationRule(func(ctx context.Context) error {
+		if FromContext(ctx) == nil {
+			return privacy.Denyf("viewer-context is missing")
+		}
+		// Skip to the next privacy rule.
+		return privacy.Skip
+	})
+}
+`))
+
+var ownerRulesTpl = template.Must(template.New("owner").Parse(`
+// {{ .Schema }}{{ .Edge }}QueryRule limits queries of {{ .Schema }} entities to the ones whose {{ .EdgeName }} is the viewer.
+func {{ .Schema }}{{ .Edge }}QueryRule() privacy.QueryRule {
+	return privacy.{{ .Schema }}QueryRuleFunc(func(ctx context.Context, q *gen.{{ .Schema }}Query) error {
+		v := FromContext(ctx)
+		if v == nil {
+			return privacy.Denyf("viewer-context is missing")
+		}
+		q.Where({{ .Pkg }}.Has{{ .Edge }}With({{ .OwnerPkg }}.ID(v.ID)))
+		// Skip to the next privacy rule.
+		return privacy.Skip
+	})
+}
+
+// {{ .Schema }}{{ .Edge }}MutationRule limits mutations of {{ .Schema }} entities to the ones whose {{ .EdgeName }} is the viewer.
+func {{ .Schema }}{{ .Edge }}MutationRule() privacy.MutationRule {
+	return privacy.{{ .Schema }}MutationRuleFunc(func(ctx context.Context, m *gen.{{ .Schema }}Mutation) error {
+		v := FromContext(ctx)
+		if v == nil {
+			return privacy.Denyf("viewer-context is missing")
+		}
+		m.Where({{ .Pkg }}.Has{{ .Edge }}With({{ .OwnerPkg }}.ID(v.ID)))
+		// Skip to the next privacy rule.
+		return privacy.Skip
+	})
+}
+`))
+
+// PolicyRules are the rules wapiti offers to build policies from.
+var PolicyRules = []string{"DenyIfNoViewer", "OwnerOnly", "AlwaysAllow", "AlwaysDeny"}
+
+// viewerIDs maps the ID types of schemas to the Go types of the Viewer.ID.
+var viewerIDs = map[string]string{"int": "int", "int64": "int64", "string": "string", "uuid": "uuid.UUID"}
+
+// Policy is a privacy policy to add to a schema.
+type Policy struct {
+	Schema *load.Schema
+	// Mutation and Query are the rules of the policy in the order they are evaluated, one of the PolicyRules each.
+	Mutation, Query []string
+	// Owner is the edge to the owner of the entities, used by the OwnerOnly rule.
+	Owner string
+}
+
+// AddPolicy adds the Policy method to the schema. If missing a rule package holding the rules used by the policy is
+// created next to the schema package. The generated privacy package must exist. Calls Reload afterwards.
+func (w *Wapiti) AddPolicy(p *Policy) error {
+	files, err := w.policyFiles(p)
+	if err != nil {
+		return err
+	}
+	return w.writeFiles(files)
+}
+
+// policyFiles returns the contents of the files changed by adding the policy, keyed by file name.
+func (w *Wapiti) policyFiles(p *Policy) (map[string][]byte, error) {
+	if f, _ := w.method(p.Schema, "Policy"); f != nil {
+		return nil, fmt.Errorf("schema %s already has a policy", p.Schema.Name)
+	}
+	genPkg, err := w.generated("privacy")
+	if err != nil {
+		return nil, err
+	}
+	rulePkg := path.Join(genPkg, "rule")
+	dir, err := filepath.Abs(w.cfg.SchemaPath)
+	if err != nil {
+		return nil, err
+	}
+	ruleDir := filepath.Join(filepath.Dir(dir), "rule")
+	files := make(map[string][]byte)
+	// The policy itself.
+	mut, err := p.rules(p.Mutation, "Mutation")
+	if err != nil {
+		return nil, err
+	}
+	qry, err := p.rules(p.Query, "Query")
+	if err != nil {
+		return nil, err
+	}
+	file := w.file(p.Schema)
+	if file == nil {
+		return nil, fmt.Errorf("no file found for schema %s", p.Schema.Name)
+	}
+	src, err := w.source(file)
+	if err != nil {
+		return nil, err
+	}
+	b := new(bytes.Buffer)
+	if err := policyTpl.Execute(b, map[string]interface{}{"Schema": p.Schema.Name, "Mutation": mut, "Query": qry}); err != nil {
+		return nil, fmt.Errorf("executing template %s: %w", policyTpl.Name(), err)
+	}
+	if err := src.appendDecl(b.String()); err != nil {
+		return nil, err
+	}
+	src.imports = map[string]string{"privacy": path.Join(genPkg, "privacy"), "rule": rulePkg}
+	if files[src.name], err = src.output(); err != nil {
+		return nil, err
+	}
+	// The rule package skeleton.
+	rf := filepath.Join(ruleDir, "rule.go")
+	if _, err := os.Stat(ruleDir); os.IsNotExist(err) {
+		id := "int"
+		if o := w.ownerSchema(p); o != nil {
+			if t, ok := viewerIDs[idType(o)]; ok {
+				id = t
+			}
+		}
+		b := new(bytes.Buffer)
+		if err := rulePkgTpl.Execute(b, map[string]string{"Gen": genPkg, "ID": id}); err != nil {
+			return nil, fmt.Errorf("executing template %s: %w", rulePkgTpl.Name(), err)
+		}
+		s, err := parseSource(rf, b.Bytes())
+		if err != nil {
+			return nil, err
+		}
+		if files[rf], err = s.output(); err != nil {
+			return nil, err
+		}
+	}
+	// The rules filtering by owner.
+	if p.uses("OwnerOnly") {
+		o := w.ownerSchema(p)
+		if o == nil {
+			return nil, fmt.Errorf("schema %s has no unique edge %q to an owner", p.Schema.Name, p.Owner)
+		}
+		if _, err := w.generated(strings.ToLower(p.Schema.Name)); err != nil {
+			return nil, err
+		}
+		name := filepath.Join(ruleDir, strings.ToLower(p.Schema.Name)+".go")
+		b, err := ioutil.ReadFile(name)
+		switch {
+		case os.IsNotExist(err):
+			b = []byte("package rule\n")
+		case err != nil:
+			return nil, fmt.Errorf("reading file %s: %w", name, err)
+		}
+		s, err := parseSource(name, b)
+		if err != nil {
+			return nil, err
+		}
+		b2 := new(bytes.Buffer)
+		if err := ownerRulesTpl.Execute(b2, map[string]string{
+			"Schema":   p.Schema.Name,
+			"Edge":     pascal(p.Owner),
+			"EdgeName": p.Owner,
+			"Pkg":      strings.ToLower(p.Schema.Name),
+			"OwnerPkg": strings.ToLower(o.Name),
+		}); err != nil {
+			return nil, fmt.Errorf("executing template %s: %w", ownerRulesTpl.Name(), err)
+		}
+		if s.file.Scope.Lookup(p.Schema.Name+pascal(p.Owner)+"QueryRule") == nil {
+			if err := s.appendDecl(b2.String()); err != nil {
+				return nil, err
+			}
+		}
+		s.imports = map[string]string{
+			"context":                      "context",
+			"gen":                          genPkg,
+			"privacy":                      path.Join(genPkg, "privacy"),
+			strings.ToLower(p.Schema.Name): path.Join(genPkg, strings.ToLower(p.Schema.Name)),
+			strings.ToLower(o.Name):        path.Join(genPkg, strings.ToLower(o.Name)),
+		}
+		if files[name], err = s.output(); err != nil {
+			return nil, err
+		}
+	}
+	return files, nil
+}
+
+// rules returns the code of the given rules of the policy. kind is either Mutation or Query.
+func (p *Policy) rules(rs []string, kind string) ([]string, error) {
+	cs := make([]string, len(rs))
+	for i, r := range rs {
+		switch r {
+		case "AlwaysAllow":
+			cs[i] = "privacy.AlwaysAllowRule()"
+		case "AlwaysDeny":
+			cs[i] = "privacy.AlwaysDenyRule()"
+		case "DenyIfNoViewer":
+			cs[i] = "rule.DenyIfNoViewer()"
+		case "OwnerOnly":
+			if p.Owner == "" {
+				return nil, fmt.Errorf("the OwnerOnly rule needs the edge to the owner")
+			}
+			cs[i] = fmt.Sprintf("rule.%s%s%sRule()", p.Schema.Name, pascal(p.Owner), kind)
+		default:
+			return nil, fmt.Errorf("unknown rule %q: choose from %s", r, strings.Join(PolicyRules, ", "))
+		}
+	}
+	return cs, nil
+}
+
+// uses reports whether the policy uses the given rule.
+func (p *Policy) uses(r string) bool {
+	return contains(p.Mutation, r) || contains(p.Query, r)
+}
+
+// ownerSchema returns the schema the owner edge of the policy points to. nil if there is no such edge or it is not
+// unique.
+func (w *Wapiti) ownerSchema(p *Policy) *load.Schema {
+	for _, e := range p.Schema.Edges {
+		if e.Name == p.Owner && e.Unique {
+			return w.LookupNode(e.Type)
+		}
+	}
+	return nil
+}
+
+// idType returns the type of the ID field of the schema.
+func idType(s *load.Schema) string {
+	for _, f := range s.Fields {
+		if f.Name == "id" {
+			return typeName(f)
+		}
+	}
+	return "int"
+}
+
+// policyRuleSuggestions describe the PolicyRules.
+var policyRuleSuggestions = []prompt.Suggest{
+	{Text: "DenyIfNoViewer", Description: "Deny if there is no viewer in the context"},
+	{Text: "OwnerOnly", Description: "Limit to entities owned by the viewer via an edge"},
+	{Text: "AlwaysAllow", Description: "Allow"},
+	{Text: "AlwaysDeny", Description: "Deny"},
+}
+
+// NewPolicy asks the user whether to add a privacy policy to the given node and what rules it consists of. Returns
+// nil, nil if the user does not want to add a policy.
+func (w *Wapiti) NewPolicy(s *load.Schema) (*Policy, error) {
+	// Policies can only be added once the privacy package has been generated.
+	if _, err := w.generated("privacy"); err != nil {
+		return nil, nil
+	}
+	if f, _ := w.method(s, "Policy"); f != nil || !askYesNo(false, "Add a privacy policy") {
+		return nil, nil
+	}
+	p := &Policy{Schema: s}
+	p.Mutation = splitList(ask(policyRuleSuggestions, "Mutation rules in the order they are evaluated, separated by space:"))
+	p.Query = splitList(ask(policyRuleSuggestions, "Query rules in the order they are evaluated, separated by space:"))
+	if p.uses("OwnerOnly") {
+		var sgst []prompt.Suggest
+		for _, e := range s.Edges {
+			if e.Unique {
+				sgst = append(sgst, prompt.Suggest{Text: e.Name, Description: e.Type})
+			}
+		}
+		p.Owner = ask(sgst, "Edge to the owner of the %s:", s.Name)
+	}
+	if err := w.AddPolicy(p); err != nil {
+		return nil, err
+	}
+	return p, nil
+}
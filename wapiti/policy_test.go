@@ -0,0 +1,92 @@
+package wapiti
+
+import (
+	"entgo.io/ent/entc/load"
+	"entgo.io/ent/schema/field"
+	"github.com/masseelch/wapiti/wapiti/config"
+	"github.com/stretchr/testify/require"
+	"go/parser"
+	"go/token"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestPolicyFiles(t *testing.T) {
+	dir := t.TempDir()
+	schemaDir := filepath.Join(dir, "ent", "schema")
+	for _, d := range []string{schemaDir, filepath.Join(dir, "ent", "privacy"), filepath.Join(dir, "ent", "pet")} {
+		require.NoError(t, os.MkdirAll(d, 0755))
+	}
+	require.NoError(t, ioutil.WriteFile(filepath.Join(schemaDir, "pet.go"), []byte(`package schema
+
+import "entgo.io/ent"
+
+// Pet holds the schema definition for the Pet entity.
+type Pet struct {
+	ent.Schema
+}
+`), 0644))
+	fset := token.NewFileSet()
+	pkgs, err := parser.ParseDir(fset, schemaDir, nil, parser.ParseComments)
+	require.NoError(t, err)
+	pet := &load.Schema{Name: "Pet", Edges: []*load.Edge{{Name: "owner", Type: "User", Unique: true}, {Name: "friends", Type: "Pet"}}}
+	w := &Wapiti{
+		cfg:  &config.Config{SchemaPath: schemaDir},
+		fset: fset,
+		ast:  pkgs["schema"],
+		spec: &load.SchemaSpec{PkgPath: "example.com/app/ent/schema", Schemas: []*load.Schema{
+			pet,
+			{Name: "User", Fields: []*load.Field{{Name: "id", Info: &field.TypeInfo{Type: field.TypeUUID}}}},
+		}},
+	}
+
+	files, err := w.policyFiles(&Policy{Schema: pet, Mutation: []string{"DenyIfNoViewer", "OwnerOnly"}, Query: []string{"OwnerOnly", "AlwaysAllow"}, Owner: "owner"})
+	require.NoError(t, err)
+	require.Len(t, files, 3)
+	require.Equal(t, `package schema
+
+import (
+	"entgo.io/ent"
+	"example.com/app/ent/privacy"
+	"example.com/app/ent/rule"
+)
+
+// Pet holds the schema definition for the Pet entity.
+type Pet struct {
+	ent.Schema
+}
+
+// Policy defines the privacy policy of the Pet.
+func (Pet) Policy() ent.Policy {
+	return privacy.Policy{
+		Mutation: privacy.MutationPolicy{
+			rule.DenyIfNoViewer(),
+			rule.PetOwnerMutationRule(),
+		},
+		Query: privacy.QueryPolicy{
+			rule.PetOwnerQueryRule(),
+			privacy.AlwaysAllowRule(),
+		},
+	}
+}
+`, string(files[filepath.Join(schemaDir, "pet.go")]))
+	rule := string(files[filepath.Join(dir, "ent", "rule", "rule.go")])
+	require.Contains(t, rule, "\t\"github.com/google/uuid\"\n")
+	require.Contains(t, rule, "\tID uuid.UUID\n")
+	owner := string(files[filepath.Join(dir, "ent", "rule", "pet.go")])
+	require.Contains(t, owner, "\tgen \"example.com/app/ent\"\n")
+	require.Contains(t, owner, "\t\"example.com/app/ent/user\"\n")
+	require.Contains(t, owner, "q.Where(pet.HasOwnerWith(user.ID(v.ID)))")
+	require.Contains(t, owner, "func PetOwnerMutationRule() privacy.MutationRule {")
+
+	for _, p := range []*Policy{
+		{Schema: pet, Mutation: []string{"AllowAdmin"}},
+		{Schema: pet, Query: []string{"OwnerOnly"}},
+		{Schema: pet, Query: []string{"OwnerOnly"}, Owner: "friends"},
+	} {
+		_, err := w.policyFiles(p)
+		require.Error(t, err, p)
+	}
+}
@@ -7,8 +7,10 @@ import (
 	"github.com/c-bata/go-prompt"
 	"github.com/logrusorgru/aurora/v3"
 	"github.com/masseelch/wapiti/wapiti/sillyname"
+	"go/ast"
 	"regexp"
 	"strings"
+	"unicode"
 )
 
 const (
@@ -17,8 +19,9 @@ const (
 )
 
 var (
-	nodeNameRgx  = regexp.MustCompile("[A-Z][A-Za-z]*")
-	fieldNameRgx = regexp.MustCompile("[A-Za-z][A-Za-z1-9_-]*")
+	nodeNameRgx  = regexp.MustCompile("^[A-Z][A-Za-z]*$")
+	fieldNameRgx = regexp.MustCompile("^[A-Za-z][A-Za-z0-9_-]*$")
+	enumNameRgx  = regexp.MustCompile("^[A-Za-z][A-Za-z0-9_]*$")
 	types        = []prompt.Suggest{
 		{Text: "int"}, {Text: "uint"},
 		{Text: "int8"}, {Text: "int16"}, {Text: "int32"}, {Text: "int64"},
@@ -33,7 +36,26 @@ var (
 		{Text: "enum"},
 		{Text: "other"},
 	}
-	yesNo = []prompt.Suggest{{Text: "yes"}, {Text: "no"}}
+	errSchemaName = errors.New("schema names must begin with uppercase and contain only letters")
+	errFieldName  = errors.New("field names must begin with a letter and only contain alphanumeric characters, underscores and dashes")
+	errEdgeName   = errors.New("edge names must begin with a letter and only contain alphanumeric characters, underscores and dashes")
+	// errShortcut is returned by the steps of the wizard if the user entered a shortcut instead of an answer.
+	errShortcut = errors.New("shortcut")
+	shortcuts   = []prompt.Suggest{
+		{Text: ":undo", Description: "Undo the last change"},
+		{Text: ":redo", Description: "Redo the last undone change"},
+	}
+	yesNo      = []prompt.Suggest{{Text: "yes"}, {Text: "no"}}
+	directions = []prompt.Suggest{
+		{Text: "to", Description: "The edge is owned by this schema (edge.To)"},
+		{Text: "from", Description: "The edge is a back-reference to an edge of the other schema (edge.From)"},
+	}
+	relations = []prompt.Suggest{
+		{Text: "O2O", Description: "One-to-one"},
+		{Text: "O2M", Description: "One-to-many"},
+		{Text: "M2O", Description: "Many-to-one"},
+		{Text: "M2M", Description: "Many-to-many"},
+	}
 )
 
 // SelectNode asks the user what node to edit. If an unknown name is given a new schema with that name is created and
@@ -44,20 +66,24 @@ func (w *Wapiti) SelectNode() (*load.Schema, error) {
 	for _, n := range w.spec.Schemas {
 		sgst = append(sgst, prompt.Suggest{Text: n.Name, Description: fmt.Sprintf("Edit the %s node", n.Name)})
 	}
-	name := ask(sgst, "Name of the schema to create or update (e.g. %s):", aurora.Yellow(sillyname.Schema()))
+	name := ask(append(sgst, shortcuts...), "Name of the schema to create or update (e.g. %s):", aurora.Yellow(sillyname.Schema()))
 	// If the user sent us nothing stop the execution.
 	if name == "" {
 		return nil, nil
 	}
+	if w.shortcut(name) {
+		return w.SelectNode()
+	}
 	// Validate the name.
 	if !nodeNameRgx.MatchString(name) {
-		return nil, errors.New("schema names must begin with uppercase and contain only letters")
+		return nil, errSchemaName
 	}
 	// If there is no schema of this name yet, create it.
 	s := w.LookupNode(name)
 	if s == nil {
 		// Create a new schema.
-		if err := w.CreateSchema(name); err != nil {
+		id := ask(idTypes, "Type of the ID field [%s]:", aurora.Yellow("int"))
+		if err := w.CreateSchema(name, id); err != nil {
 			return nil, err
 		}
 		s = w.LookupNode(name)
@@ -65,6 +91,14 @@ func (w *Wapiti) SelectNode() (*load.Schema, error) {
 	return s, nil
 }
 
+// lookup returns the node with the given name or an error if there is no such node.
+func (w *Wapiti) lookup(n string) (*load.Schema, error) {
+	if s := w.LookupNode(n); s != nil {
+		return s, nil
+	}
+	return nil, fmt.Errorf("unknown schema %s", n)
+}
+
 // LookupNode looks for a node with the name and returns it. nil if no such node exists.
 func (w *Wapiti) LookupNode(n string) *load.Schema {
 	for _, s := range w.spec.Schemas {
@@ -76,43 +110,769 @@ func (w *Wapiti) LookupNode(n string) *load.Schema {
 }
 
 type Field struct {
-	Schema    *load.Schema
-	Name      string
-	Type      string
-	Optional  bool
-	Nillable  bool
-	Immutable bool
+	Schema *load.Schema
+	Name   string
+	Type   string
+	// Enums holds the values of an enum field.
+	Enums []EnumValue
+	// Default is the Go expression of the default value, e.g. `"active"` or `time.Now`.
+	Default string
+	// UpdateDefault is the Go expression of the value set on updates, e.g. `time.Now`.
+	UpdateDefault string
+	// Validators are the validators of the field in the order they are applied.
+	Validators []Validator
+	Optional   bool
+	Nillable   bool
+	Immutable  bool
+	Unique     bool
+	Sensitive  bool
+	// StorageKey is the name of the column. Empty for the default.
+	StorageKey string
+	// StructTag is the struct tag of the field in the generated entity, e.g. `json:"name,omitempty"`.
+	StructTag string
+	// SchemaType maps dialect names like "mysql" to the database type of the column.
+	SchemaType map[string]string
+	Comment    string
+	// GoType is the custom Go type of the field, e.g. `*models.Point`. Required for json and other fields.
+	GoType string
+	// Imports are the import paths of the packages GoType references, keyed by package name.
+	Imports map[string]string
+}
+
+// setType changes the type of the field. The default values and the Go type are dropped if the type changes, since
+// they are Go expressions and types of the old type.
+func (f *Field) setType(t string) {
+	if t == f.Type {
+		return
+	}
+	f.Type = t
+	f.Default, f.UpdateDefault = "", ""
+	f.GoType, f.Imports = "", nil
+}
+
+// EnumValue is a value of an enum field. Name is the name of the generated Go constant and may be empty if it equals
+// the value.
+type EnumValue struct {
+	Name  string
+	Value string
+}
+
+// Validator is a validator option of a field like MaxLen(10). Args holds the Go code of the arguments.
+type Validator struct {
+	Name string
+	Args string
+}
+
+// code returns the option of the validator.
+func (v Validator) code() string {
+	return v.Name + "(" + v.Args + ")"
 }
 
 // NewField asks the user what field to add to the given node. Returns nil, nil if the user wants to stop adding fields.
 func (w *Wapiti) NewField(s *load.Schema) (*load.Field, error) {
 	f := &Field{Schema: s}
 	// Ask for the field name.
-	f.Name = ask(nil, "Name of the field to add (press <return> to stop adding fields):")
+	f.Name = ask(shortcuts, "Name of the field to add (press <return> to stop adding fields):")
 	// If the user sent us nothing stop the execution.
 	if f.Name == "" {
 		return nil, nil
 	}
+	if w.shortcut(f.Name) {
+		return nil, errShortcut
+	}
 	// Validate the name.
 	if !fieldNameRgx.MatchString(f.Name) {
-		return nil, errors.New("field names must begin with a letter and only contain alphanumeric characters, underscores and dashes")
+		return nil, errFieldName
 	}
-	// Ask for the field type.
-	f.Type = ask(types, "Field type [%s]:", aurora.Yellow(defaultType))
-	if f.Type == "" {
-		f.Type = defaultType
+	if hasField(s, f.Name) {
+		return nil, fmt.Errorf("field %s already exists", f.Name)
+	}
+	// Ask for the field type and options.
+	f.Type = defaultType
+	if err := w.askFieldType(f); err != nil {
+		return nil, err
+	}
+	if err := w.askFieldOptions(f); err != nil {
+		return nil, err
 	}
-	// TODO: Maybe only ask "Do you want set any other option?" - if so wizard them to all the rest (optional, nil, immutable, storage_key, struct_tag)
-	// Ask if the field is optional.
-	f.Optional = "no" == ask(yesNo, "Is this field required on creation (optional) (yes/no) [%s]", aurora.Yellow("yes"))
-	// Ask if the field is nillable.
-	f.Nillable = "yes" == ask(yesNo, "Can this field be nil (yes/no) (nillable) [%s]", aurora.Yellow("no"))
-	// Ask if the field is immutable.
-	f.Immutable = "yes" == ask(yesNo, "Can this field be updated after creation (immutable) (yes/no) [%s]", aurora.Yellow("no"))
 	// Add the field to the schema.
 	return w.AddField(f)
 }
 
+// EditField asks the user how to change the field with the given name of the given node. The current name, type and
+// options of the field are suggested as defaults.
+func (w *Wapiti) EditField(s *load.Schema, name string) (*load.Field, error) {
+	f, err := w.FieldOf(s, name)
+	if err != nil {
+		return nil, err
+	}
+	// Ask for the new field name.
+	if n := ask(nil, "New name of the field [%s]:", aurora.Yellow(f.Name)); n != "" {
+		f.Name = n
+	}
+	if !fieldNameRgx.MatchString(f.Name) {
+		return nil, errFieldName
+	}
+	if f.Name != name && hasField(s, f.Name) {
+		return nil, fmt.Errorf("field %s already exists", f.Name)
+	}
+	// Ask for the field type and options.
+	if err := w.askFieldType(f); err != nil {
+		return nil, err
+	}
+	if err := w.askFieldOptions(f); err != nil {
+		return nil, err
+	}
+	// Replace the field in the schema.
+	return w.UpdateField(s, name, f)
+}
+
+// FieldOf returns a Field with the name, type and options of the field with the given name of the node.
+func (w *Wapiti) FieldOf(s *load.Schema, name string) (*Field, error) {
+	lf := w.lookupField(s.Name, name)
+	if lf == nil {
+		return nil, fmt.Errorf("unknown field %s", name)
+	}
+	f := &Field{
+		Schema:     s,
+		Name:       lf.Name,
+		Type:       typeName(lf),
+		Optional:   lf.Optional,
+		Nillable:   lf.Nillable,
+		Immutable:  lf.Immutable,
+		Unique:     lf.Unique,
+		Sensitive:  lf.Sensitive,
+		StorageKey: lf.StorageKey,
+		StructTag:  lf.Tag,
+		SchemaType: lf.SchemaType,
+		Comment:    lf.Comment,
+	}
+	for _, e := range lf.Enums {
+		v := EnumValue{Name: e.N, Value: e.V}
+		if v.Name == v.Value {
+			v.Name = ""
+		}
+		f.Enums = append(f.Enums, v)
+	}
+	// Values that cannot be loaded (like the default) are taken from the source.
+	if src, lit, i, err := w.fieldElem(s, name); err == nil {
+		for j, c := range chain(lit.Elts[i]) {
+			switch {
+			case j == 0 && len(c.Args) == 2:
+				f.GoType = src.typeOf(c.Args[1])
+			case c.Fun.(*ast.SelectorExpr).Sel.Name == "GoType" && len(c.Args) == 1:
+				f.GoType = src.typeOf(c.Args[0])
+			}
+		}
+		if f.GoType == "uuid.UUID" {
+			f.GoType = ""
+		}
+		for _, o := range src.options(lit.Elts[i]) {
+			switch o.name {
+			case "Default":
+				f.Default = o.args()
+			case "UpdateDefault":
+				f.UpdateDefault = o.args()
+			default:
+				if contains(validators[f.Type], o.name) {
+					f.Validators = append(f.Validators, Validator{Name: o.name, Args: o.args()})
+				}
+			}
+		}
+	}
+	return f, nil
+}
+
+// askFieldType asks for the type of the field and the details the type needs. The current type is the default.
+func (w *Wapiti) askFieldType(f *Field) error {
+	if t := ask(types, "Field type [%s]:", aurora.Yellow(f.Type)); t != "" {
+		f.setType(t)
+	}
+	switch f.Type {
+	case "enum":
+		f.GoType = ""
+		return askEnum(f)
+	case "json":
+		return w.askGoType(f, true, "Go type of the field, e.g. []string, map[string]interface{} or *models.Data")
+	case "other":
+		if err := w.askGoType(f, true, "Go type of the field implementing driver.Valuer and sql.Scanner, e.g. models.Point"); err != nil {
+			return err
+		}
+		askSchemaType(f)
+		if len(f.SchemaType) == 0 {
+			return errors.New("other fields need a schema type")
+		}
+	}
+	return nil
+}
+
+// askGoType asks the given question for the Go type of the field and resolves the packages it references. The
+// current Go type is the default. If the Go type is not required the user can remove it.
+func (w *Wapiti) askGoType(f *Field, required bool, question string) error {
+	if required {
+		if f.GoType != "" {
+			question += fmt.Sprintf(" [%s]", aurora.Yellow(f.GoType))
+		}
+	} else {
+		question += fmt.Sprintf(" (press <return> for %s)", aurora.Yellow(orNone(f.GoType)))
+	}
+	t := ask(nil, question+":")
+	switch {
+	case t == "" && required && f.GoType == "":
+		return fmt.Errorf("%s fields need a Go type", f.Type)
+	case t == "":
+		return nil
+	case t == "-" && !required:
+		f.GoType, f.Imports = "", nil
+		return nil
+	}
+	t, pkgs, err := w.ResolveGoType(t)
+	if err != nil {
+		return err
+	}
+	f.GoType, f.Imports = t, pkgs
+	return nil
+}
+
+// askEnum asks for the values and the default value of an enum field.
+func askEnum(f *Field) error {
+	var cur []string
+	for _, e := range f.Enums {
+		if e.Name != "" && e.Name != e.Value {
+			cur = append(cur, e.Name+"="+e.Value)
+		} else {
+			cur = append(cur, e.Value)
+		}
+	}
+	q := "Values of the enum, separated by space (use name=value for named values)"
+	if len(cur) > 0 {
+		q += fmt.Sprintf(" [%s]", aurora.Yellow(strings.Join(cur, " ")))
+	}
+	if a := ask(nil, q+":"); a != "" {
+		cur = splitList(a)
+	}
+	es, err := ParseEnums(cur)
+	if err != nil {
+		return err
+	}
+	f.Enums = es
+	// Ask for the default value.
+	sgst := make([]prompt.Suggest, len(es))
+	for i, e := range es {
+		sgst[i] = prompt.Suggest{Text: e.Value}
+	}
+	d := ask(sgst, "Default value (press <return> for %s):", aurora.Yellow(orNone(f.Default)))
+	switch d {
+	case "":
+	case "-":
+		f.Default = ""
+	default:
+		f.Default = quote(d)
+	}
+	return nil
+}
+
+// ParseEnums parses a list of enum values. Named values are given as name=value.
+func ParseEnums(vs []string) ([]EnumValue, error) {
+	if len(vs) == 0 {
+		return nil, errors.New("enums need at least one value")
+	}
+	es := make([]EnumValue, len(vs))
+	seen := make(map[string]bool, len(vs))
+	for i, v := range vs {
+		if j := strings.Index(v, "="); j >= 0 {
+			es[i] = EnumValue{Name: v[:j], Value: v[j+1:]}
+			if !enumNameRgx.MatchString(es[i].Name) {
+				return nil, fmt.Errorf("invalid enum name %q: names must begin with a letter and only contain alphanumeric characters and underscores", es[i].Name)
+			}
+			if es[i].Value == "" {
+				return nil, fmt.Errorf("missing value for enum name %q", es[i].Name)
+			}
+		} else {
+			es[i] = EnumValue{Value: v}
+			if !enumNameRgx.MatchString(v) {
+				return nil, fmt.Errorf("invalid enum value %q: values must begin with a letter and only contain alphanumeric characters and underscores (use name=value for other values)", v)
+			}
+		}
+		if seen[es[i].Value] {
+			return nil, fmt.Errorf("duplicate enum value %q", es[i].Value)
+		}
+		seen[es[i].Value] = true
+	}
+	return es, nil
+}
+
+// orNone returns s or "none" if s is empty.
+func orNone(s string) string {
+	if s == "" {
+		return "none"
+	}
+	return s + ", - for none"
+}
+
+// askFieldOptions asks for the options of the field. The current options are the defaults.
+func (w *Wapiti) askFieldOptions(f *Field) error {
+	// Ask for the default value. Enums ask for it together with their values.
+	if f.Type != "enum" {
+		if err := askDefault(f); err != nil {
+			return err
+		}
+	}
+	if err := askValidators(f); err != nil {
+		return err
+	}
+	// All other options are only asked for if the user wants to.
+	set := f.Optional || f.Nillable || f.Immutable || f.Unique || f.Sensitive ||
+		f.StorageKey != "" || f.StructTag != "" || len(f.SchemaType) > 0 || f.Comment != ""
+	if !askYesNo(set, "Configure advanced options") {
+		return nil
+	}
+	// Ask if the field is optional.
+	f.Optional = !askYesNo(!f.Optional, "Is this field required on creation (optional)")
+	// Ask if the field is nillable. The builder of json fields has no such option.
+	if f.Type == "json" {
+		f.Nillable = false
+	} else {
+		f.Nillable = askYesNo(f.Nillable, "Can this field be nil (nillable)")
+	}
+	// Ask if the field is immutable.
+	f.Immutable = !askYesNo(!f.Immutable, "Can this field be updated after creation (immutable)")
+	switch f.Type {
+	case "bool", "enum", "time", "json":
+		f.Unique = false
+	default:
+		f.Unique = askYesNo(f.Unique, "Must the values of this field be unique (unique)")
+	}
+	if f.Type == "string" || f.Type == "text" {
+		f.Sensitive = askYesNo(f.Sensitive, "Is this field sensitive and must not be printed or serialized (sensitive)")
+	} else {
+		f.Sensitive = false
+	}
+	f.StorageKey = askString(f.StorageKey, "Column name (storage key)")
+	f.StructTag = askString(f.StructTag, "Struct tag, e.g. json:\"%s,omitempty\"", f.Name)
+	switch f.Type {
+	case "bool", "other":
+	default:
+		askSchemaType(f)
+	}
+	switch f.Type {
+	case "json", "other", "enum":
+	default:
+		if err := w.askGoType(f, false, "Custom Go type of the field, e.g. models.Level"); err != nil {
+			return err
+		}
+	}
+	f.Comment = askString(f.Comment, "Comment")
+	return nil
+}
+
+// askString asks the given question for an optional string value. cur is returned if the user sends nothing, an empty
+// string if the user sends "-".
+func askString(cur, question string, args ...interface{}) string {
+	switch a := ask(nil, question+" (press <return> for %s):", append(args, aurora.Yellow(orNone(cur)))...); a {
+	case "":
+		return cur
+	case "-":
+		return ""
+	default:
+		return a
+	}
+}
+
+// askSchemaType asks for the database type of the field per dialect.
+func askSchemaType(f *Field) {
+	st := make(map[string]string, len(f.SchemaType))
+	for k, v := range f.SchemaType {
+		st[k] = v
+	}
+	for _, d := range dialects {
+		if t := askString(st[d.name], "Column type for %s (schema type)", d.name); t != "" {
+			st[d.name] = t
+		} else {
+			delete(st, d.name)
+		}
+	}
+	f.SchemaType = nil
+	if len(st) > 0 {
+		f.SchemaType = st
+	}
+}
+
+// defaults are the suggested default values per field type.
+var defaults = map[string][]prompt.Suggest{
+	"bool": {{Text: "true"}, {Text: "false"}},
+	"time": {{Text: "time.Now", Description: "Set the current time"}},
+	"uuid": {{Text: "uuid.New", Description: "Generate a random UUID"}},
+}
+
+// askDefault asks for the default value of the field and, for time fields, the value to set on updates.
+func askDefault(f *Field) error {
+	switch f.Type {
+	case "json", "other":
+		return nil
+	}
+	switch d := ask(defaults[f.Type], "Default value (press <return> for %s):", aurora.Yellow(orNone(f.Default))); d {
+	case "":
+	case "-":
+		f.Default = ""
+	default:
+		expr, err := DefaultExpr(f.Type, d)
+		if err != nil {
+			return err
+		}
+		f.Default = expr
+	}
+	update := f.UpdateDefault != ""
+	f.UpdateDefault = ""
+	if f.Type == "time" && askYesNo(update, "Set the field to the current time on updates (update default)") {
+		f.UpdateDefault = "time.Now"
+	}
+	return nil
+}
+
+// askValidators asks for the validators of the field, if its type has any.
+func askValidators(f *Field) error {
+	names := validators[f.Type]
+	// Drop the validators the type of the field does not offer, e.g. after the type changed.
+	var cur []string
+	vs := f.Validators[:0]
+	for _, v := range f.Validators {
+		if contains(names, v.Name) {
+			vs = append(vs, v)
+			cur = append(cur, v.code())
+		}
+	}
+	f.Validators = vs
+	if len(names) == 0 {
+		return nil
+	}
+	q := "Add validators to the field (validation)"
+	if len(cur) > 0 {
+		q = fmt.Sprintf("Change the validators %s of the field (validation)", strings.Join(cur, ", "))
+	}
+	if !askYesNo(false, q) {
+		return nil
+	}
+	sgst := []prompt.Suggest{{Text: "-", Description: "Remove all validators"}}
+	for _, n := range names {
+		sgst = append(sgst, prompt.Suggest{Text: n})
+	}
+	for {
+		n := ask(sgst, "Validator to add (press <return> to stop adding validators):")
+		switch n {
+		case "":
+			return nil
+		case "-":
+			f.Validators = nil
+			continue
+		}
+		var arg string
+		switch n {
+		case "MinLen", "MaxLen":
+			arg = ask(nil, "Length:")
+		case "Match":
+			arg = ask(nil, "Regular expression:")
+		case "Min", "Max":
+			arg = ask(nil, "Value:")
+		case "Range":
+			arg = ask(nil, "Minimum and maximum, separated by space:")
+		}
+		v, err := NewValidator(f.Type, n, arg)
+		if err != nil {
+			fmt.Println(aurora.Red(err))
+			continue
+		}
+		// A validator given twice replaces the former one.
+		replaced := false
+		for i := range f.Validators {
+			if f.Validators[i].Name == v.Name {
+				f.Validators[i], replaced = v, true
+			}
+		}
+		if !replaced {
+			f.Validators = append(f.Validators, v)
+		}
+	}
+}
+
+// fieldActions are the actions the user can take on the fields of an existing node.
+var fieldActions = []prompt.Suggest{
+	{Text: "add", Description: "Add fields, edges and indexes"},
+	{Text: "edit", Description: "Change the name, type or options of a field"},
+	{Text: "remove", Description: "Remove a field"},
+}
+
+// ManageFields lists the fields of the given node and lets the user edit or remove them until the user chooses to add
+// new fields.
+func (w *Wapiti) ManageFields(s *load.Schema) error {
+	for len(s.Fields) > 0 {
+		fmt.Println(aurora.Cyan(fmt.Sprintf("\nFields of %s:", s.Name)))
+		var sgst []prompt.Suggest
+		for _, f := range s.Fields {
+			fmt.Printf("  %s\n", describeField(f))
+			sgst = append(sgst, prompt.Suggest{Text: f.Name, Description: typeName(f)})
+		}
+		var err error
+		switch a := ask(append(fieldActions, shortcuts...), "What do you want to do (add/edit/remove) [%s]:", aurora.Yellow("add")); a {
+		case "", "add":
+			return nil
+		case "edit":
+			var f *load.Field
+			if f, err = w.EditField(s, ask(sgst, "Name of the field to edit:")); err == nil {
+				fmt.Printf(fieldUpdatedFormat, aurora.Cyan(f.Name), aurora.Cyan(s.Name))
+			}
+		case "remove":
+			n := ask(sgst, "Name of the field to remove:")
+			if err = w.RemoveField(s, n); err == nil {
+				fmt.Printf(fieldRemovedFormat, aurora.Cyan(n), aurora.Cyan(s.Name))
+			}
+		default:
+			if !w.shortcut(a) {
+				err = fmt.Errorf("unknown action %q", a)
+			}
+		}
+		if err != nil && !w.skip(err) {
+			return err
+		}
+		// The node is gone if the user undid its creation.
+		if s = w.LookupNode(s.Name); s == nil {
+			return nil
+		}
+	}
+	return nil
+}
+
+// describeField returns the name, type and options of the loaded field.
+func describeField(f *load.Field) string {
+	d := fmt.Sprintf("%s %s", f.Name, typeName(f))
+	for _, o := range []struct {
+		set  bool
+		name string
+	}{
+		{f.Optional, "optional"},
+		{f.Nillable, "nillable"},
+		{f.Immutable, "immutable"},
+		{f.Unique, "unique"},
+		{f.Default, "default"},
+		{f.Position != nil && f.Position.MixedIn, "mixin"},
+	} {
+		if o.set {
+			d += " " + o.name
+		}
+	}
+	return d
+}
+
+type Edge struct {
+	Schema   *load.Schema
+	Name     string
+	Type     string
+	Inverse  bool
+	Ref      string
+	Unique   bool
+	Required bool
+	// Table is the name of the join table of a M2M edge, Columns are the foreign-key columns of the edge. Both are
+	// optional and only set on edges that are not back-references.
+	Table   string
+	Columns []string
+}
+
+// NewEdge asks the user what edge to add to the given node. If the edge is owned by the given node the user may add a
+// back-reference to the other node. Returns nil, nil if the user wants to stop adding edges.
+func (w *Wapiti) NewEdge(s *load.Schema) (*load.Edge, error) {
+	e := &Edge{Schema: s}
+	// Ask for the edge name.
+	e.Name = ask(shortcuts, "Name of the edge to add (press <return> to stop adding edges):")
+	// If the user sent us nothing stop the execution.
+	if e.Name == "" {
+		return nil, nil
+	}
+	if w.shortcut(e.Name) {
+		return nil, errShortcut
+	}
+	// Validate the name.
+	if !fieldNameRgx.MatchString(e.Name) {
+		return nil, errEdgeName
+	}
+	// Ask for the schema the edge points to.
+	var sgst []prompt.Suggest
+	for _, n := range w.spec.Schemas {
+		sgst = append(sgst, prompt.Suggest{Text: n.Name})
+	}
+	e.Type = ask(sgst, "Schema the edge points to [%s]:", aurora.Yellow(s.Name))
+	if e.Type == "" {
+		e.Type = s.Name
+	}
+	t := w.LookupNode(e.Type)
+	if t == nil {
+		return nil, fmt.Errorf("unknown schema %s", e.Type)
+	}
+	// Ask for the direction of the edge.
+	e.Inverse = "from" == ask(directions, "Direction of the edge (to/from) [%s]:", aurora.Yellow("to"))
+	// Back-references need to know the edge they reference.
+	if e.Inverse {
+		sgst = nil
+		for _, ref := range t.Edges {
+			if !ref.Inverse && ref.Type == s.Name {
+				sgst = append(sgst, prompt.Suggest{Text: ref.Name})
+			}
+		}
+		e.Ref = ask(sgst, "Name of the edge on %s this edge references:", aurora.Yellow(t.Name))
+		if e.Ref == "" {
+			return nil, errors.New("back-references must reference an edge")
+		}
+	}
+	// Ask for the cardinality of the relation.
+	rel := strings.ToUpper(ask(relations, "Relation between %s and %s (O2O/O2M/M2O/M2M) [%s]:", s.Name, t.Name, aurora.Yellow("O2M")))
+	if rel == "" {
+		rel = "O2M"
+	}
+	var inverseUnique bool
+	var err error
+	e.Unique, inverseUnique, err = Cardinality(rel)
+	if err != nil {
+		return nil, err
+	}
+	// Ask if the edge is required.
+	e.Required = "yes" == ask(yesNo, "Is this edge required on creation (required) (yes/no) [%s]", aurora.Yellow("no"))
+	// Offer to add the back-reference.
+	var ref string
+	if !e.Inverse {
+		ref = ask(nil, "Name of the back-reference on %s (press <return> to skip):", aurora.Yellow(t.Name))
+		if ref != "" && !fieldNameRgx.MatchString(ref) {
+			return nil, errEdgeName
+		}
+	}
+	// Add the edge and the back-reference to the schemas.
+	return w.AddEdgeWithBackRef(e, ref, inverseUnique)
+}
+
+// Cardinality returns whether an edge and its back-reference are unique for the given relation (O2O, O2M, M2O or M2M)
+// seen from the schema owning the edge.
+func Cardinality(rel string) (unique, inverseUnique bool, err error) {
+	switch strings.ToUpper(rel) {
+	case "O2O":
+		return true, true, nil
+	case "O2M":
+		return false, true, nil
+	case "M2O":
+		return true, false, nil
+	case "M2M":
+		return false, false, nil
+	}
+	return false, false, fmt.Errorf("unknown relation %q", rel)
+}
+
+type Index struct {
+	Schema     *load.Schema
+	Fields     []string
+	Edges      []string
+	Unique     bool
+	StorageKey string
+}
+
+// NewIndex asks the user what index to add to the given node. Returns nil, nil if the user wants to stop adding indexes.
+func (w *Wapiti) NewIndex(s *load.Schema) (*load.Index, error) {
+	i := &Index{Schema: s}
+	// Ask for the fields of the index.
+	var sgst []prompt.Suggest
+	for _, f := range s.Fields {
+		sgst = append(sgst, prompt.Suggest{Text: f.Name})
+	}
+	i.Fields = splitList(ask(append(sgst, shortcuts...), "Fields of the index to add, separated by space (press <return> to stop adding indexes):"))
+	if len(i.Fields) == 0 {
+		return nil, nil
+	}
+	if len(i.Fields) == 1 && w.shortcut(i.Fields[0]) {
+		return nil, errShortcut
+	}
+	for _, n := range i.Fields {
+		if !hasField(s, n) {
+			return nil, fmt.Errorf("unknown field %s", n)
+		}
+	}
+	// Ask for the edges of the index.
+	sgst = nil
+	for _, e := range s.Edges {
+		sgst = append(sgst, prompt.Suggest{Text: e.Name})
+	}
+	if len(sgst) > 0 {
+		i.Edges = splitList(ask(sgst, "Edges of the index, separated by space (press <return> to skip):"))
+		for _, n := range i.Edges {
+			if !hasEdge(s, n) {
+				return nil, fmt.Errorf("unknown edge %s", n)
+			}
+		}
+	}
+	// Ask if the index is unique.
+	i.Unique = "yes" == ask(yesNo, "Is this index unique (yes/no) [%s]", aurora.Yellow("no"))
+	// Ask for the storage key.
+	i.StorageKey = ask(nil, "Storage key of the index (press <return> to use the default):")
+	// Add the index to the schema.
+	return w.AddIndex(i)
+}
+
+// hasField reports whether the schema has a field with the given name.
+func hasField(s *load.Schema, n string) bool {
+	for _, f := range s.Fields {
+		if f.Name == n {
+			return true
+		}
+	}
+	return false
+}
+
+// hasEdge reports whether the schema has an edge with the given name.
+func hasEdge(s *load.Schema, n string) bool {
+	for _, e := range s.Edges {
+		if e.Name == n {
+			return true
+		}
+	}
+	return false
+}
+
+// splitList splits a list of names separated by spaces or commas.
+func splitList(s string) []string {
+	return strings.FieldsFunc(s, func(r rune) bool { return r == ',' || unicode.IsSpace(r) })
+}
+
+// shortcut runs the command if the answer is a shortcut. Reports whether it was one.
+func (w *Wapiti) shortcut(answer string) bool {
+	var err error
+	switch answer {
+	case ":undo":
+		err = w.Undo()
+	case ":redo":
+		err = w.Redo()
+	default:
+		return false
+	}
+	switch {
+	case errors.Is(err, ErrNotWritten):
+	case err != nil:
+		fmt.Println(aurora.Red(err))
+	default:
+		fmt.Println(aurora.Green(strings.TrimPrefix(answer, ":") + " done"))
+	}
+	return true
+}
+
+// askYesNo asks the user the given yes/no question. def is returned if the user sends nothing.
+func askYesNo(def bool, question string, args ...interface{}) bool {
+	d := "no"
+	if def {
+		d = "yes"
+	}
+	switch ask(yesNo, question+" (yes/no) [%s]", append(args, aurora.Yellow(d))...) {
+	case "yes", "y":
+		return true
+	case "no", "n":
+		return false
+	}
+	return def
+}
+
 // ask asks the user the given question and returns the answer. Ensures question and prompt have a fresh line.
 func ask(s []prompt.Suggest, question string, args ...interface{}) string {
 	if !strings.HasPrefix(question, "\n") {
@@ -23,3 +23,13 @@ func TestRgx(t *testing.T) {
 	require.NotRegexp(t, fieldNameRgx, "age?")
 	require.NotRegexp(t, fieldNameRgx, "age_%")
 }
+
+func TestFieldSetType(t *testing.T) {
+	f := &Field{Type: "time", Default: "time.Now", UpdateDefault: "time.Now", GoType: "models.Time", Imports: map[string]string{"models": "example.com/models"}}
+	f.setType("time")
+	require.Equal(t, "time.Now", f.Default)
+	require.Equal(t, "time.Now", f.UpdateDefault)
+	require.Equal(t, "models.Time", f.GoType)
+	f.setType("json")
+	require.Equal(t, &Field{Type: "json"}, f)
+}
@@ -0,0 +1,247 @@
+package wapiti
+
+import (
+	"bytes"
+	"encoding/json"
+	"entgo.io/ent/entc/load"
+	"fmt"
+	"go/ast"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"text/template"
+)
+
+var extractedMixinTpl = template.Must(template.New("extracted").Parse(`package {{ .Package }}
+
+import (
+	"entgo.io/ent"
+	"entgo.io/ent/schema/mixin"
+)
+
+// {{ .Name }} holds the fields shared by {{ .Schemas }}.
+type {{ .Name }} struct {
+	mixin.Schema
+}
+`))
+
+// ExtractMixin moves the given fields into a new mixin with the given name and adds the mixin to the schemas instead.
+// The fields must be declared equally in all schemas. If no schemas are given all schemas declaring the fields are
+// used, at least two are required then. Calls Reload afterwards.
+func (w *Wapiti) ExtractMixin(name string, fields, schemas []string) error {
+	if !nodeNameRgx.MatchString(name) {
+		return errSchemaName
+	}
+	if len(fields) == 0 {
+		return fmt.Errorf("no fields to extract")
+	}
+	if w.declares(name) {
+		return fmt.Errorf("type %s already exists", name)
+	}
+	ss, err := w.sharing(fields, schemas)
+	if err != nil {
+		return err
+	}
+	files, err := w.extractMixin(name, fields, ss)
+	if err != nil {
+		return err
+	}
+	return w.writeFiles(files)
+}
+
+// sharing returns the schemas declaring all of the given fields equally. If names are given these schemas are
+// returned, otherwise all schemas declaring the fields are.
+func (w *Wapiti) sharing(fields, names []string) ([]*load.Schema, error) {
+	var ss []*load.Schema
+	if len(names) > 0 {
+		for _, n := range names {
+			s, err := w.lookup(n)
+			if err != nil {
+				return nil, err
+			}
+			ss = append(ss, s)
+		}
+	} else {
+		for _, s := range w.spec.Schemas {
+			if declaresFields(s, fields) {
+				ss = append(ss, s)
+			}
+		}
+		if len(ss) < 2 {
+			return nil, fmt.Errorf("the fields %s are not declared by at least two schemas", strings.Join(fields, ", "))
+		}
+	}
+	for _, n := range fields {
+		var ref *load.Field
+		for _, s := range ss {
+			f := ownField(s, n)
+			if f == nil {
+				return nil, fmt.Errorf("field %s is not declared in %s.Fields()", n, s.Name)
+			}
+			if ref == nil {
+				ref = f
+			} else if !equalFields(ref, f) {
+				return nil, fmt.Errorf("field %s of %s differs from the one of %s", n, s.Name, ss[0].Name)
+			}
+		}
+	}
+	return ss, nil
+}
+
+// extractMixin returns the contents of the files changed by extracting the fields of the schemas into the mixin with
+// the given name, keyed by file name.
+func (w *Wapiti) extractMixin(name string, fields []string, ss []*load.Schema) (map[string][]byte, error) {
+	files := make(map[string][]byte)
+	srcs := make(map[*ast.File]*source)
+	// The sources of the files to change. Methods declared in the same file share the source.
+	sourceOf := func(s *load.Schema, method string) (*source, error) {
+		f, _ := w.method(s, method)
+		if f == nil {
+			return nil, fmt.Errorf("schema %s has no %s() method", s.Name, method)
+		}
+		if src, ok := srcs[f]; ok {
+			return src, nil
+		}
+		src, err := w.source(f)
+		if err != nil {
+			return nil, err
+		}
+		// Imports only used by the extracted fields must be removed.
+		src.imports = importsOf(src.file)
+		srcs[f] = src
+		return src, nil
+	}
+	// Create the mixin with the fields as declared by the first schema.
+	ref, err := sourceOf(ss[0], "Fields")
+	if err != nil {
+		return nil, err
+	}
+	schemas := make([]string, len(ss))
+	for i, s := range ss {
+		schemas[i] = s.Name
+	}
+	b := new(bytes.Buffer)
+	if err := extractedMixinTpl.Execute(b, map[string]string{
+		"Package": ref.file.Name.Name,
+		"Name":    name,
+		"Schemas": strings.Join(schemas, ", "),
+	}); err != nil {
+		return nil, fmt.Errorf("executing template %s: %w", extractedMixinTpl.Name(), err)
+	}
+	mf := filepath.Join(filepath.Dir(ref.name), strings.ToLower(name+".go"))
+	if _, err := os.Stat(mf); err == nil {
+		return nil, fmt.Errorf("file %s already exists", mf)
+	}
+	m, err := parseSource(mf, b.Bytes())
+	if err != nil {
+		return nil, err
+	}
+	for _, n := range fields {
+		lit, i := ref.elem(ref.method(ss[0].Name, "Fields"), "field", n)
+		if i < 0 {
+			return nil, fmt.Errorf("field %s is not declared in %s.Fields()", n, ss[0].Name)
+		}
+		if err := m.appendToMethod(name, "Fields", fieldsTpl, "[]ent.Field", ref.text(lit.Elts[i])); err != nil {
+			return nil, err
+		}
+	}
+	// The fields may reference packages imported by the schema file.
+	m.imports = importsOf(ref.file)
+	if files[mf], err = m.output(); err != nil {
+		return nil, err
+	}
+	// Replace the fields of the schemas by the mixin.
+	for _, s := range ss {
+		src, err := sourceOf(s, "Fields")
+		if err != nil {
+			return nil, err
+		}
+		for _, n := range fields {
+			lit, i := src.elem(src.method(s.Name, "Fields"), "field", n)
+			if i < 0 {
+				return nil, fmt.Errorf("field %s is not declared in %s.Fields()", n, s.Name)
+			}
+			if err := src.removeElem(lit, i); err != nil {
+				return nil, err
+			}
+		}
+		// The Mixin() method may be declared in another file. If there is none it is added next to Fields().
+		if f, _ := w.method(s, "Mixin"); f != nil {
+			if src, err = sourceOf(s, "Mixin"); err != nil {
+				return nil, err
+			}
+		}
+		if err := src.appendToMethod(s.Name, "Mixin", mixinTpl, "[]ent.Mixin", name+"{}"); err != nil {
+			return nil, err
+		}
+	}
+	for _, src := range srcs {
+		if files[src.name], err = src.output(); err != nil {
+			return nil, err
+		}
+	}
+	return files, nil
+}
+
+// declares reports whether the schema package declares a type with the given name.
+func (w *Wapiti) declares(name string) bool {
+	for _, f := range w.ast.Files {
+		if o := f.Scope.Lookup(name); o != nil && o.Kind == ast.Typ {
+			return true
+		}
+	}
+	return false
+}
+
+// importsOf returns the import paths of the file keyed by the name they are referenced by. Blank and dot imports are
+// left out.
+func importsOf(f *ast.File) map[string]string {
+	is := make(map[string]string, len(f.Imports))
+	for _, i := range f.Imports {
+		n := importName(i)
+		if n == "_" || n == "." {
+			continue
+		}
+		if p, err := strconv.Unquote(i.Path.Value); err == nil {
+			is[n] = p
+		}
+	}
+	return is
+}
+
+// declaresFields reports whether the schema itself declares all of the given fields.
+func declaresFields(s *load.Schema, fields []string) bool {
+	for _, n := range fields {
+		if ownField(s, n) == nil {
+			return false
+		}
+	}
+	return true
+}
+
+// ownField returns the field with the given name declared by the schema itself. nil if there is no such field or it
+// is mixed in.
+func ownField(s *load.Schema, name string) *load.Field {
+	for _, f := range s.Fields {
+		if f.Name == name && (f.Position == nil || !f.Position.MixedIn) {
+			return f
+		}
+	}
+	return nil
+}
+
+// equalFields reports whether both fields are declared equally, ignoring their position.
+func equalFields(a, b *load.Field) bool {
+	ac, bc := *a, *b
+	ac.Position, bc.Position = nil, nil
+	ja, err := json.Marshal(ac)
+	if err != nil {
+		return false
+	}
+	jb, err := json.Marshal(bc)
+	if err != nil {
+		return false
+	}
+	return bytes.Equal(ja, jb)
+}
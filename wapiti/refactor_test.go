@@ -0,0 +1,188 @@
+package wapiti
+
+import (
+	"entgo.io/ent/entc/load"
+	"entgo.io/ent/schema/field"
+	"github.com/masseelch/wapiti/wapiti/config"
+	"github.com/stretchr/testify/require"
+	"go/parser"
+	"go/token"
+	"io/ioutil"
+	"path/filepath"
+	"testing"
+)
+
+func TestExtractMixin(t *testing.T) {
+	dir := t.TempDir()
+	for n, src := range map[string]string{
+		"pet.go": `package schema
+
+import (
+	"entgo.io/ent"
+	"entgo.io/ent/schema/field"
+	"example.com/app/models"
+	"time"
+)
+
+// Pet holds the schema definition for the Pet entity.
+type Pet struct {
+	ent.Schema
+}
+
+// Fields of the Pet.
+func (Pet) Fields() []ent.Field {
+	return []ent.Field{
+		field.String("name"),
+		field.Time("created_at").Default(time.Now).Immutable(),
+		field.Int("level").GoType(models.Level(0)),
+	}
+}
+`,
+		"user.go": `package schema
+
+import (
+	"entgo.io/ent"
+	"entgo.io/ent/schema/field"
+	"example.com/app/models"
+	"time"
+)
+
+// User holds the schema definition for the User entity.
+type User struct {
+	ent.Schema
+}
+
+// Fields of the User.
+func (User) Fields() []ent.Field {
+	return []ent.Field{
+		field.Time("created_at").Default(time.Now).Immutable(),
+		field.Int("level").GoType(models.Level(0)),
+	}
+}
+`,
+		"user_mixin.go": `package schema
+
+import "entgo.io/ent"
+
+// Mixin of the User.
+func (User) Mixin() []ent.Mixin {
+	return []ent.Mixin{
+		Base{},
+	}
+}
+`,
+	} {
+		require.NoError(t, ioutil.WriteFile(filepath.Join(dir, n), []byte(src), 0644))
+	}
+	fset := token.NewFileSet()
+	pkgs, err := parser.ParseDir(fset, dir, nil, parser.ParseComments)
+	require.NoError(t, err)
+	createdAt := func(i int) *load.Field {
+		return &load.Field{Name: "created_at", Info: &field.TypeInfo{Type: field.TypeTime}, Default: true, Immutable: true, Position: &load.Position{Index: i}}
+	}
+	level := func(i int) *load.Field {
+		return &load.Field{Name: "level", Info: &field.TypeInfo{Type: field.TypeInt, Ident: "models.Level"}, Position: &load.Position{Index: i}}
+	}
+	w := &Wapiti{
+		cfg:  &config.Config{SchemaPath: dir},
+		fset: fset,
+		ast:  pkgs["schema"],
+		spec: &load.SchemaSpec{Schemas: []*load.Schema{
+			{Name: "Pet", Fields: []*load.Field{{Name: "name", Info: &field.TypeInfo{Type: field.TypeString}}, createdAt(1), level(2)}},
+			{Name: "User", Fields: []*load.Field{createdAt(0), level(1)}},
+			{Name: "Group", Fields: []*load.Field{{Name: "created_at", Info: &field.TypeInfo{Type: field.TypeTime}}}},
+		}},
+	}
+
+	ss, err := w.sharing([]string{"created_at", "level"}, nil)
+	require.NoError(t, err)
+	require.Len(t, ss, 2)
+	_, err = w.sharing([]string{"created_at"}, []string{"Pet", "Group"})
+	require.EqualError(t, err, "field created_at of Group differs from the one of Pet")
+	_, err = w.sharing([]string{"name"}, nil)
+	require.Error(t, err)
+	require.True(t, w.declares("User"))
+	require.False(t, w.declares("Audit"))
+
+	files, err := w.extractMixin("Audit", []string{"created_at", "level"}, ss)
+	require.NoError(t, err)
+	require.Len(t, files, 4)
+	require.Equal(t, `package schema
+
+import (
+	"entgo.io/ent"
+	"entgo.io/ent/schema/field"
+	"entgo.io/ent/schema/mixin"
+	"example.com/app/models"
+	"time"
+)
+
+// Audit holds the fields shared by Pet, User.
+type Audit struct {
+	mixin.Schema
+}
+
+// Fields of the Audit.
+func (Audit) Fields() []ent.Field {
+	return []ent.Field{
+		field.Time("created_at").Default(time.Now).Immutable(),
+		field.Int("level").GoType(models.Level(0)),
+	}
+}
+`, string(files[filepath.Join(dir, "audit.go")]))
+	require.Equal(t, `package schema
+
+import (
+	"entgo.io/ent"
+	"entgo.io/ent/schema/field"
+)
+
+// Pet holds the schema definition for the Pet entity.
+type Pet struct {
+	ent.Schema
+}
+
+// Fields of the Pet.
+func (Pet) Fields() []ent.Field {
+	return []ent.Field{
+		field.String("name"),
+	}
+}
+
+// Mixin of the Pet.
+func (Pet) Mixin() []ent.Mixin {
+	return []ent.Mixin{
+		Audit{},
+	}
+}
+`, string(files[filepath.Join(dir, "pet.go")]))
+	require.Equal(t, `package schema
+
+import (
+	"entgo.io/ent"
+)
+
+// User holds the schema definition for the User entity.
+type User struct {
+	ent.Schema
+}
+
+// Fields of the User.
+func (User) Fields() []ent.Field {
+	return []ent.Field{}
+}
+`, string(files[filepath.Join(dir, "user.go")]))
+	// The mixin is added to the Mixin() method of the User declared in another file.
+	require.Equal(t, `package schema
+
+import "entgo.io/ent"
+
+// Mixin of the User.
+func (User) Mixin() []ent.Mixin {
+	return []ent.Mixin{
+		Base{},
+		Audit{},
+	}
+}
+`, string(files[filepath.Join(dir, "user_mixin.go")]))
+}
@@ -0,0 +1,209 @@
+package wapiti
+
+import (
+	"bytes"
+	"entgo.io/ent/entc/gen"
+	"errors"
+	"fmt"
+	"go/ast"
+	"go/token"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+// RenameSchema renames the schema old to new. The struct, its methods, the references to the type in the package and
+// its doc comments are rewritten. If the schema resides in a file named after it the file is renamed too. If
+// keepTable is set an entsql.Annotation is added to keep the name of the database table. Calls Reload afterwards.
+func (w *Wapiti) RenameSchema(old, new string, keepTable bool) error {
+	s, err := w.lookup(old)
+	if err != nil {
+		return err
+	}
+	if !nodeNameRgx.MatchString(new) {
+		return errSchemaName
+	}
+	if w.LookupNode(new) != nil {
+		return fmt.Errorf("schema %s already exists", new)
+	}
+	var table string
+	if keepTable {
+		t, err := gen.NewType(&gen.Config{}, s)
+		if err != nil {
+			return err
+		}
+		// Nothing to do if the table name is already fixed by an annotation.
+		if a := t.EntSQL(); a == nil || a.Table == "" {
+			table = t.Table()
+		}
+	}
+	schemaFile := w.file(s)
+	annotationsFile, _ := w.method(s, "Annotations")
+	if annotationsFile == nil {
+		annotationsFile = schemaFile
+	}
+	src, err := w.source(schemaFile)
+	if err != nil {
+		return err
+	}
+	if filepath.Base(src.name) == strings.ToLower(old+".go") {
+		if _, err := os.Stat(w.schemaFile(new)); err == nil {
+			return fmt.Errorf("file %s already exists", w.schemaFile(new))
+		}
+	}
+	var (
+		changes []*journalFile
+		skipped bool
+	)
+	err = w.renameFiles(old, new, table, schemaFile, annotationsFile, &changes, &skipped)
+	// Files written before an error are journaled as well, so that they can be undone.
+	if len(changes) > 0 {
+		if rerr := w.record(changes...); err == nil {
+			err = rerr
+		}
+	}
+	switch {
+	case err != nil:
+		return err
+	case skipped:
+		return ErrNotWritten
+	}
+	return w.Reload()
+}
+
+// renameFiles writes the files of the package with the type old renamed to new. The file of the schema is renamed if
+// it is named after it. The annotation keeping the table name is added if table is set. The written files are added
+// to changes, skipped is set if a file was not written.
+func (w *Wapiti) renameFiles(old, new, table string, schemaFile, annotationsFile *ast.File, changes *[]*journalFile, skipped *bool) error {
+	for _, f := range w.ast.Files {
+		src, err := w.source(f)
+		if err != nil {
+			return err
+		}
+		if err := src.apply(renameEdits(src, old, new)...); err != nil {
+			return err
+		}
+		if f == annotationsFile && table != "" {
+			if err := src.appendToMethod(new, "Annotations", annotationsTpl, "[]schema.Annotation", fmt.Sprintf("entsql.Annotation{Table: %q}", table)); err != nil {
+				return err
+			}
+		}
+		name := src.name
+		if f == schemaFile && filepath.Base(name) == strings.ToLower(old+".go") {
+			name = filepath.Join(filepath.Dir(name), strings.ToLower(new+".go"))
+		}
+		src.fixImports(imports)
+		b, err := src.formatted()
+		if err != nil {
+			return err
+		}
+		if name == src.name && bytes.Equal(b, src.src) {
+			continue
+		}
+		jf, err := w.writeFile(name, b)
+		if err := collect(changes, skipped, jf, err); err != nil {
+			return err
+		}
+		if name != src.name {
+			jf, err := w.removeFile(src.name)
+			if err := collect(changes, skipped, jf, err); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// collect adds the change to changes. Changes that were not written are noted in skipped.
+func collect(changes *[]*journalFile, skipped *bool, jf *journalFile, err error) error {
+	switch {
+	case errors.Is(err, ErrNotWritten):
+		*skipped = true
+	case err != nil:
+		return err
+	default:
+		*changes = append(*changes, jf)
+	}
+	return nil
+}
+
+// renameEdits returns the edits renaming the type old to new: its declaration, the references to it and the mentions
+// of it in its doc comment and the doc comments of its methods. Identifiers resolving to something else, like local
+// variables, struct fields or selected members, are left alone. So are files importing a package named old.
+func renameEdits(s *source, old, new string) []edit {
+	for _, i := range s.file.Imports {
+		if importName(i) == old {
+			return nil
+		}
+	}
+	var (
+		decl  *ast.TypeSpec
+		docs  []*ast.CommentGroup
+		names = make(map[*ast.Ident]bool)
+	)
+	for _, d := range s.file.Decls {
+		switch d := d.(type) {
+		case *ast.GenDecl:
+			for _, sp := range d.Specs {
+				if ts, ok := sp.(*ast.TypeSpec); ok && ts.Name.Name == old {
+					decl = ts
+					docs = append(docs, ts.Doc)
+					if len(d.Specs) == 1 {
+						docs = append(docs, d.Doc)
+					}
+				}
+			}
+		case *ast.FuncDecl:
+			if d.Recv != nil && len(d.Recv.List) == 1 && identName(deref(d.Recv.List[0].Type)) == old {
+				docs = append(docs, d.Doc)
+			}
+		}
+	}
+	// Identifiers named old that do not reference a type.
+	ast.Inspect(s.file, func(n ast.Node) bool {
+		switch n := n.(type) {
+		case *ast.SelectorExpr:
+			names[n.Sel] = true
+		case *ast.Field:
+			for _, id := range n.Names {
+				names[id] = true
+			}
+		case *ast.FuncDecl:
+			names[n.Name] = true
+		case *ast.KeyValueExpr:
+			if id, ok := n.Key.(*ast.Ident); ok {
+				names[id] = true
+			}
+		case *ast.LabeledStmt:
+			names[n.Label] = true
+		case *ast.BranchStmt:
+			names[n.Label] = true
+		}
+		return true
+	})
+	var edits []edit
+	ast.Inspect(s.file, func(n ast.Node) bool {
+		id, ok := n.(*ast.Ident)
+		if !ok || id.Name != old || names[id] {
+			return true
+		}
+		// Identifiers declared in the file are resolved by the parser, the ones declared in other files are not.
+		if id.Obj == nil || decl != nil && id.Obj.Decl == decl {
+			edits = append(edits, edit{pos: id.Pos(), end: id.End(), text: new})
+		}
+		return true
+	})
+	rgx := regexp.MustCompile(`\b` + regexp.QuoteMeta(old) + `\b`)
+	for _, g := range docs {
+		if g == nil {
+			continue
+		}
+		for _, c := range g.List {
+			for _, m := range rgx.FindAllStringIndex(c.Text, -1) {
+				edits = append(edits, edit{pos: c.Pos() + token.Pos(m[0]), end: c.Pos() + token.Pos(m[1]), text: new})
+			}
+		}
+	}
+	return edits
+}
@@ -0,0 +1,123 @@
+package wapiti
+
+import (
+	"entgo.io/ent/entc/load"
+	"github.com/masseelch/wapiti/wapiti/config"
+	"github.com/stretchr/testify/require"
+	"go/parser"
+	"go/token"
+	"io/ioutil"
+	"path/filepath"
+	"testing"
+)
+
+func TestRenameEdits(t *testing.T) {
+	s, err := parseSource("pet.go", []byte(`package schema
+
+// Pet holds the schema definition for the Pet entity.
+type Pet struct {
+	ent.Schema
+}
+
+// Fields of the Pet.
+func (Pet) Fields() []ent.Field {
+	return []ent.Field{field.String("Pet")}
+}
+
+// Edges of the Pet.
+func (Pet) Edges() []ent.Edge {
+	return []ent.Edge{edge.To("children", Pet.Type), edge.To("Pets", Pets.Type)}
+}
+`))
+	require.NoError(t, err)
+	require.NoError(t, s.apply(renameEdits(s, "Pet", "Animal")...))
+	require.Equal(t, `package schema
+
+// Animal holds the schema definition for the Animal entity.
+type Animal struct {
+	ent.Schema
+}
+
+// Fields of the Animal.
+func (Animal) Fields() []ent.Field {
+	return []ent.Field{field.String("Pet")}
+}
+
+// Edges of the Animal.
+func (Animal) Edges() []ent.Edge {
+	return []ent.Edge{edge.To("children", Animal.Type), edge.To("Pets", Pets.Type)}
+}
+`, string(s.src))
+}
+
+func TestRenameEditsReferences(t *testing.T) {
+	s, err := parseSource("user.go", []byte(`package schema
+
+// User holds the schema definition for the User entity. Every User has a Pet.
+type User struct {
+	ent.Schema
+	Pet string
+}
+
+// Edges of the User.
+func (User) Edges() []ent.Edge {
+	// A Pet belongs to one User.
+	return []ent.Edge{edge.To("pets", Pet.Type), edge.To("friends", other.Pet.Type)}
+}
+
+func pets(u User) []Pet {
+	ps := []Pet{{Name: u.Pet}}
+	Pet := ps[0]
+	return append(ps, Pet)
+}
+`))
+	require.NoError(t, err)
+	require.NoError(t, s.apply(renameEdits(s, "Pet", "Animal")...))
+	require.Equal(t, `package schema
+
+// User holds the schema definition for the User entity. Every User has a Pet.
+type User struct {
+	ent.Schema
+	Pet string
+}
+
+// Edges of the User.
+func (User) Edges() []ent.Edge {
+	// A Pet belongs to one User.
+	return []ent.Edge{edge.To("pets", Animal.Type), edge.To("friends", other.Pet.Type)}
+}
+
+func pets(u User) []Animal {
+	ps := []Animal{{Name: u.Pet}}
+	Pet := ps[0]
+	return append(ps, Pet)
+}
+`, string(s.src))
+
+	s, err = parseSource("user.go", []byte("package schema\n\nimport Pet \"example.com/pet\"\n\nvar _ = Pet.Type\n"))
+	require.NoError(t, err)
+	require.Empty(t, renameEdits(s, "Pet", "Animal"))
+}
+
+func TestRenameSchemaFileExists(t *testing.T) {
+	dir := t.TempDir()
+	for n, src := range map[string]string{
+		"pet.go":    "package schema\n\ntype Pet struct {\n\tent.Schema\n}\n",
+		"animal.go": "package schema\n\n// Animal is not a schema.\nconst Animal = 1\n",
+	} {
+		require.NoError(t, ioutil.WriteFile(filepath.Join(dir, n), []byte(src), 0644))
+	}
+	fset := token.NewFileSet()
+	pkgs, err := parser.ParseDir(fset, dir, nil, parser.ParseComments)
+	require.NoError(t, err)
+	w := &Wapiti{
+		cfg:  &config.Config{SchemaPath: dir},
+		fset: fset,
+		ast:  pkgs["schema"],
+		spec: &load.SchemaSpec{Schemas: []*load.Schema{{Name: "Pet"}}},
+	}
+	require.EqualError(t, w.RenameSchema("Pet", "Animal", false), "file "+filepath.Join(dir, "animal.go")+" already exists")
+	b, err := ioutil.ReadFile(filepath.Join(dir, "pet.go"))
+	require.NoError(t, err)
+	require.Contains(t, string(b), "type Pet struct")
+}
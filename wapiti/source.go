@@ -0,0 +1,240 @@
+package wapiti
+
+import (
+	"bytes"
+	"fmt"
+	"go/ast"
+	"go/format"
+	"go/parser"
+	"go/token"
+	"sort"
+	"strconv"
+)
+
+// edit replaces the source between pos and end with text. If pos equals end the text is inserted at pos.
+type edit struct {
+	pos, end token.Pos
+	text     string
+}
+
+// source holds the contents of a schema file together with its syntax tree. Edits are applied to the raw source and
+// the result is re-parsed, so that comments and formatting of untouched code are kept as they are.
+type source struct {
+	name string
+	fset *token.FileSet
+	file *ast.File
+	src  []byte
+	// imports are the import paths of packages referenced by added code in addition to the well-known ones, keyed by
+	// package name.
+	imports map[string]string
+}
+
+// parseSource parses the given src of the file with the given name.
+func parseSource(name string, src []byte) (*source, error) {
+	s := &source{name: name, fset: token.NewFileSet()}
+	if err := s.parse(src); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+// parse replaces the source with src and parses it.
+func (s *source) parse(src []byte) error {
+	f, err := parser.ParseFile(s.fset, s.name, src, parser.ParseComments)
+	if err != nil {
+		return fmt.Errorf("parsing %s: %w", s.name, err)
+	}
+	s.file = f
+	s.src = src
+	return nil
+}
+
+// offset returns the offset of the position in the source.
+func (s *source) offset(p token.Pos) int {
+	return s.fset.Position(p).Offset
+}
+
+// apply applies the edits to the source and re-parses it. The positions of the edits must not overlap.
+func (s *source) apply(edits ...edit) error {
+	sort.SliceStable(edits, func(i, j int) bool { return edits[i].pos > edits[j].pos })
+	src := append([]byte(nil), s.src...)
+	for _, e := range edits {
+		start, end := s.offset(e.pos), s.offset(e.end)
+		src = append(src[:start], append([]byte(e.text), src[end:]...)...)
+	}
+	return s.parse(src)
+}
+
+// appendDecl appends the given code to the end of the file.
+func (s *source) appendDecl(code string) error {
+	f := s.fset.File(s.file.Pos())
+	end := f.Pos(f.Size())
+	return s.apply(edit{pos: end, end: end, text: "\n" + code})
+}
+
+// method returns the method with the given name declared on the receiver type recv. nil if there is no such method.
+func (s *source) method(recv, name string) *ast.FuncDecl {
+	for _, decl := range s.file.Decls {
+		if fn, ok := decl.(*ast.FuncDecl); ok && fn.Name.Name == name && fn.Recv != nil && len(fn.Recv.List) == 1 {
+			if r, ok := fn.Recv.List[0].Type.(*ast.Ident); ok && r.Name == recv {
+				return fn
+			}
+		}
+	}
+	return nil
+}
+
+// appendElem adds elem to the slice literal returned by the given method. typ is the type of the slice and is used
+// if the method currently returns nil.
+func (s *source) appendElem(fn *ast.FuncDecl, typ, elem string) error {
+	lit, err := s.returned(fn)
+	if err != nil {
+		return err
+	}
+	switch lit := lit.(type) {
+	case *ast.Ident:
+		if lit.Name != "nil" {
+			break
+		}
+		return s.apply(edit{pos: lit.Pos(), end: lit.End(), text: fmt.Sprintf("%s{\n%s,\n}", typ, elem)})
+	case *ast.CompositeLit:
+		if len(lit.Elts) == 0 {
+			return s.apply(edit{pos: lit.Rbrace, end: lit.Rbrace, text: "\n" + elem + ",\n"})
+		}
+		// A trailing comma means that the elements are listed one per line.
+		last := lit.Elts[len(lit.Elts)-1]
+		if bytes.ContainsRune(s.src[s.offset(last.End()):s.offset(lit.Rbrace)], ',') {
+			return s.apply(edit{pos: lit.Rbrace, end: lit.Rbrace, text: elem + ",\n"})
+		}
+		return s.apply(edit{pos: last.End(), end: last.End(), text: ",\n" + elem + ",\n"})
+	}
+	return fmt.Errorf("%s.%s() must return nil or a slice literal", s.receiver(fn), fn.Name.Name)
+}
+
+// returned returns the expression returned by the last return statement of the given method.
+func (s *source) returned(fn *ast.FuncDecl) (ast.Expr, error) {
+	if fn.Body != nil {
+		for i := len(fn.Body.List) - 1; i >= 0; i-- {
+			if r, ok := fn.Body.List[i].(*ast.ReturnStmt); ok && len(r.Results) == 1 {
+				return r.Results[0], nil
+			}
+		}
+	}
+	return nil, fmt.Errorf("%s.%s() has no return statement", s.receiver(fn), fn.Name.Name)
+}
+
+// receiver returns the name of the receiver type of the given method.
+func (s *source) receiver(fn *ast.FuncDecl) string {
+	if fn.Recv != nil && len(fn.Recv.List) == 1 {
+		if r, ok := fn.Recv.List[0].Type.(*ast.Ident); ok {
+			return r.Name
+		}
+	}
+	return ""
+}
+
+// formatted returns the gofmt-ed source of the syntax tree.
+func (s *source) formatted() ([]byte, error) {
+	b := new(bytes.Buffer)
+	if err := format.Node(b, s.fset, s.file); err != nil {
+		return nil, fmt.Errorf("formatting %s: %w", s.name, err)
+	}
+	return b.Bytes(), nil
+}
+
+// elem returns the index of the element of the slice literal returned by the given method, whose builder chain starts
+// with a call like pkg.X("name", ...). -1 if there is no such element.
+func (s *source) elem(fn *ast.FuncDecl, pkg, name string) (*ast.CompositeLit, int) {
+	ret, err := s.returned(fn)
+	if err != nil {
+		return nil, -1
+	}
+	lit, ok := ret.(*ast.CompositeLit)
+	if !ok {
+		return nil, -1
+	}
+	for i, e := range lit.Elts {
+		calls := chain(e)
+		if len(calls) == 0 || len(calls[0].Args) == 0 {
+			continue
+		}
+		sel := calls[0].Fun.(*ast.SelectorExpr)
+		if x, ok := sel.X.(*ast.Ident); !ok || x.Name != pkg {
+			continue
+		}
+		if l, ok := calls[0].Args[0].(*ast.BasicLit); ok && l.Kind == token.STRING {
+			if v, err := strconv.Unquote(l.Value); err == nil && v == name {
+				return lit, i
+			}
+		}
+	}
+	return lit, -1
+}
+
+// replaceElem replaces the i-th element of the slice literal with code.
+func (s *source) replaceElem(lit *ast.CompositeLit, i int, code string) error {
+	return s.apply(edit{pos: lit.Elts[i].Pos(), end: lit.Elts[i].End(), text: code})
+}
+
+// removeElem removes the i-th element of the slice literal.
+func (s *source) removeElem(lit *ast.CompositeLit, i int) error {
+	switch {
+	case len(lit.Elts) == 1:
+		return s.apply(edit{pos: lit.Elts[i].Pos(), end: lit.Rbrace})
+	case i < len(lit.Elts)-1:
+		return s.apply(edit{pos: lit.Elts[i].Pos(), end: lit.Elts[i+1].Pos()})
+	default:
+		// Keep a trailing comma of the last element.
+		return s.apply(edit{pos: lit.Elts[i-1].End(), end: lit.Elts[i].End()})
+	}
+}
+
+// text returns the source of the given node.
+func (s *source) text(n ast.Node) string {
+	return string(s.src[s.offset(n.Pos()):s.offset(n.End())])
+}
+
+// options returns the calls following the root call of the builder chain e, e.g. `Optional()` and `Default("x")` for
+// field.String("name").Optional().Default("x").
+func (s *source) options(e ast.Expr) []option {
+	var opts []option
+	for _, c := range chain(e)[1:] {
+		sel := c.Fun.(*ast.SelectorExpr)
+		opts = append(opts, option{
+			name: sel.Sel.Name,
+			code: string(s.src[s.offset(sel.Sel.Pos()):s.offset(c.End())]),
+		})
+	}
+	return opts
+}
+
+// option is a call of a builder chain other than the root call.
+type option struct {
+	name, code string
+}
+
+// args returns the source of the arguments of the call.
+func (o option) args() string {
+	return o.code[len(o.name)+1 : len(o.code)-1]
+}
+
+// chain returns the calls of a builder chain like field.String("name").Optional(), starting with the root call. nil if
+// e is not a builder chain.
+func chain(e ast.Expr) []*ast.CallExpr {
+	c, ok := e.(*ast.CallExpr)
+	if !ok {
+		return nil
+	}
+	sel, ok := c.Fun.(*ast.SelectorExpr)
+	if !ok {
+		return nil
+	}
+	if _, ok := sel.X.(*ast.Ident); ok {
+		return []*ast.CallExpr{c}
+	}
+	prev := chain(sel.X)
+	if prev == nil {
+		return nil
+	}
+	return append(prev, c)
+}
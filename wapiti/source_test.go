@@ -0,0 +1,131 @@
+package wapiti
+
+import (
+	"github.com/stretchr/testify/require"
+	"testing"
+)
+
+func TestSourceAppendElem(t *testing.T) {
+	for _, tt := range []struct {
+		ret, want string
+	}{
+		{"nil", "[]ent.Field{\n\t\tfield.Int(\"age\"),\n\t}"},
+		{"[]ent.Field{}", "[]ent.Field{\n\t\tfield.Int(\"age\"),\n\t}"},
+		{"[]ent.Field{field.String(\"name\")}", "[]ent.Field{field.String(\"name\"),\n\t\tfield.Int(\"age\"),\n\t}"},
+		{"[]ent.Field{\n\t\tfield.String(\"name\"), // comment\n\t}", "[]ent.Field{\n\t\tfield.String(\"name\"), // comment\n\t\tfield.Int(\"age\"),\n\t}"},
+	} {
+		s, err := parseSource("pet.go", []byte("package schema\n\nfunc (Pet) Fields() []ent.Field {\n\treturn "+tt.ret+"\n}\n"))
+		require.NoError(t, err)
+		require.NoError(t, s.appendElem(s.method("Pet", "Fields"), "[]ent.Field", `field.Int("age")`))
+		b, err := s.formatted()
+		require.NoError(t, err)
+		require.Equal(t, "package schema\n\nfunc (Pet) Fields() []ent.Field {\n\treturn "+tt.want+"\n}\n", string(b))
+	}
+}
+
+func TestSourceAppendDecl(t *testing.T) {
+	s, err := parseSource("pet.go", []byte("package schema\n\ntype Pet struct{}\n"))
+	require.NoError(t, err)
+	require.Nil(t, s.method("Pet", "Fields"))
+	require.NoError(t, s.appendDecl("func (Pet) Fields() []ent.Field {\n\treturn nil\n}\n"))
+	require.NotNil(t, s.method("Pet", "Fields"))
+	require.Error(t, s.appendElem(s.method("Pet", "Fields"), "[]ent.Field", "x"+"("))
+}
+
+func TestSourceFixImports(t *testing.T) {
+	s, err := parseSource("pet.go", []byte(`package schema
+
+import (
+	"entgo.io/ent"
+	"entgo.io/ent/schema/edge"
+	idx "entgo.io/ent/schema/index"
+)
+
+type Pet struct {
+	ent.Schema
+}
+
+func (Pet) Fields() []ent.Field {
+	return []ent.Field{field.UUID("id", uuid.UUID{}).Default(uuid.New), field.Time("created_at").Default(time.Now)}
+}
+
+func (Pet) Indexes() []ent.Index {
+	return []ent.Index{idx.Fields("id")}
+}
+`))
+	require.NoError(t, err)
+	s.fixImports(imports)
+	b, err := s.formatted()
+	require.NoError(t, err)
+	require.Contains(t, string(b), `import (
+	"entgo.io/ent"
+	"entgo.io/ent/schema/field"
+	idx "entgo.io/ent/schema/index"
+	"github.com/google/uuid"
+	"time"
+)`)
+}
+
+func TestFixImportsOtherPackage(t *testing.T) {
+	s, err := parseSource("pet.go", []byte(`package schema
+
+import (
+	"entgo.io/ent"
+	"entgo.io/ent/schema/field"
+	"github.com/gofrs/uuid"
+)
+
+type Pet struct {
+	ent.Schema
+}
+
+func (Pet) Fields() []ent.Field {
+	return []ent.Field{field.UUID("id", uuid.UUID{})}
+}
+`))
+	require.NoError(t, err)
+	s.fixImports(imports)
+	b, err := s.formatted()
+	require.NoError(t, err)
+	require.Contains(t, string(b), `"github.com/gofrs/uuid"`)
+	require.NotContains(t, string(b), `"github.com/google/uuid"`)
+}
+
+func TestSourceElem(t *testing.T) {
+	src := "package schema\n\nfunc (Pet) Fields() []ent.Field {\n\treturn []ent.Field{\n\t\tfield.String(\"name\").MaxLen(10).Optional(),\n\t\tfield.Int(\"age\"),\n\t\tfield.Bool(\"good\"),\n\t}\n}\n"
+	fields := func(elems string) string {
+		return "package schema\n\nfunc (Pet) Fields() []ent.Field {\n\treturn []ent.Field" + elems + "\n}\n"
+	}
+	for _, tt := range []struct {
+		name string
+		want string
+	}{
+		{"name", fields("{\n\t\tfield.Int(\"age\"),\n\t\tfield.Bool(\"good\"),\n\t}")},
+		{"age", fields("{\n\t\tfield.String(\"name\").MaxLen(10).Optional(),\n\t\tfield.Bool(\"good\"),\n\t}")},
+		{"good", fields("{\n\t\tfield.String(\"name\").MaxLen(10).Optional(),\n\t\tfield.Int(\"age\"),\n\t}")},
+	} {
+		s, err := parseSource("pet.go", []byte(src))
+		require.NoError(t, err)
+		lit, i := s.elem(s.method("Pet", "Fields"), "field", tt.name)
+		require.GreaterOrEqual(t, i, 0)
+		require.NoError(t, s.removeElem(lit, i))
+		b, err := s.formatted()
+		require.NoError(t, err)
+		require.Equal(t, tt.want, string(b))
+	}
+
+	s, err := parseSource("pet.go", []byte(src))
+	require.NoError(t, err)
+	_, i := s.elem(s.method("Pet", "Fields"), "field", "unknown")
+	require.Equal(t, -1, i)
+	lit, i := s.elem(s.method("Pet", "Fields"), "field", "name")
+	require.Equal(t, []option{{"MaxLen", "MaxLen(10)"}, {"Optional", "Optional()"}}, s.options(lit.Elts[i]))
+	require.NoError(t, s.replaceElem(lit, i, `field.Text("title")`))
+	require.Contains(t, string(s.src), "\t\tfield.Text(\"title\"),\n\t\tfield.Int(\"age\"),\n")
+
+	s, err = parseSource("pet.go", []byte(fields(`{field.Int("age")}`)))
+	require.NoError(t, err)
+	lit, i = s.elem(s.method("Pet", "Fields"), "field", "age")
+	require.NoError(t, s.removeElem(lit, i))
+	require.Contains(t, string(s.src), "[]ent.Field{}")
+}
@@ -0,0 +1,550 @@
+package wapiti
+
+import (
+	"entgo.io/ent/entc/gen"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// invalidNameRgx matches the characters not allowed in field, edge and enum names.
+var invalidNameRgx = regexp.MustCompile("[^A-Za-z0-9_]+")
+
+// ImportSQL parses the CREATE TABLE, CREATE INDEX and ALTER TABLE statements of the DDL script and returns the
+// SpecFile describing the tables as ent schemas. Foreign keys become edges and tables consisting of two foreign keys
+// only become M2M edges. The returned notes list the parts of the script that cannot be expressed by ent. dialect is
+// one of mysql, postgres and sqlite3 and is detected from the script if empty. It is used to keep column types ent
+// would not choose by itself as schema types.
+func ImportSQL(src, dialect string) (*SpecFile, []string, error) {
+	d, err := parseDDL(src)
+	if err != nil {
+		return nil, nil, err
+	}
+	if dialect == "" {
+		dialect = d.dialect
+	}
+	if dialect == "" {
+		dialect = "mysql"
+	}
+	if dialectIdent(dialect) == "" {
+		return nil, nil, fmt.Errorf("unknown dialect %q: choose from mysql, postgres and sqlite3", dialect)
+	}
+	im := &importer{
+		ddl:       d,
+		dialect:   dialect,
+		schemas:   make(map[*table]*SpecSchema),
+		names:     make(map[*SpecSchema]map[string]bool),
+		edgeNames: make(map[*table]map[string]string),
+	}
+	return im.spec()
+}
+
+// importer converts the tables of a DDL script into a SpecFile.
+type importer struct {
+	*ddl
+	dialect string
+	schemas map[*table]*SpecSchema
+	// names holds the names of the fields and edges of the schemas.
+	names map[*SpecSchema]map[string]bool
+	// edgeNames maps the foreign-key columns of the tables to the names of their edges.
+	edgeNames map[*table]map[string]string
+	notes
+}
+
+// spec returns the SpecFile of the tables.
+func (im *importer) spec() (*SpecFile, []string, error) {
+	sf := new(SpecFile)
+	var joins []*table
+	for _, t := range im.tables {
+		if im.joinTable(t) {
+			joins = append(joins, t)
+			continue
+		}
+		s, err := im.schema(t)
+		if err != nil {
+			return nil, nil, err
+		}
+		for _, o := range sf.Schemas {
+			if o.Name == s.Name {
+				return nil, nil, fmt.Errorf("tables %s and %s both map to schema %s", o.Table, t.name, s.Name)
+			}
+		}
+		im.schemas[t] = s
+		im.names[s] = map[string]bool{"id": true}
+		sf.Schemas = append(sf.Schemas, s)
+	}
+	for _, t := range im.tables {
+		if s, ok := im.schemas[t]; ok {
+			im.fields(t, s)
+		}
+	}
+	for _, t := range im.tables {
+		if s, ok := im.schemas[t]; ok {
+			im.edges(t, s)
+			im.indexes(t, s)
+		}
+	}
+	for _, t := range joins {
+		im.m2m(t)
+	}
+	trimTables(sf)
+	return sf, im.notes, nil
+}
+
+// schema returns the schema of the table without fields, edges and indexes.
+func (im *importer) schema(t *table) (*SpecSchema, error) {
+	n := strings.Map(func(r rune) rune {
+		if r >= 'A' && r <= 'Z' || r >= 'a' && r <= 'z' {
+			return r
+		}
+		return -1
+	}, pascal(singular(snake(invalidNameRgx.ReplaceAllString(t.name, "_")))))
+	if !nodeNameRgx.MatchString(n) {
+		return nil, fmt.Errorf("cannot derive a schema name from table %s", t.name)
+	}
+	s := &SpecSchema{Name: n, Table: t.name}
+	var typ, raw string
+	if pk := im.primaryKey(t); pk != nil {
+		typ, _ = im.fieldType(pk)
+		raw = pk.raw
+	}
+	im.notes.primaryKey(s, t.name, t.pk, typ, raw)
+	if len(t.pk) > 1 {
+		t.indexes = append([]*tableIndex{{columns: t.pk, unique: true}}, t.indexes...)
+	}
+	return s, nil
+}
+
+// primaryKey returns the column of the primary key of the table. nil if the primary key is missing or spans multiple
+// columns.
+func (im *importer) primaryKey(t *table) *column {
+	if len(t.pk) != 1 {
+		return nil
+	}
+	return t.column(t.pk[0])
+}
+
+// joinTable reports whether the table only consists of two foreign keys referencing the primary keys of other tables.
+func (im *importer) joinTable(t *table) bool {
+	if len(t.columns) != 2 || len(t.fks) != 2 {
+		return false
+	}
+	for _, fk := range t.fks {
+		if im.target(fk) == nil || im.joinTableCandidate(im.table(fk.table)) {
+			return false
+		}
+	}
+	return !strings.EqualFold(t.fks[0].columns[0], t.fks[1].columns[0])
+}
+
+// joinTableCandidate reports whether the table has the shape of a join table. Join tables cannot be referenced by
+// M2M edges.
+func (im *importer) joinTableCandidate(t *table) bool {
+	return t != nil && len(t.columns) == 2 && len(t.fks) == 2
+}
+
+// target returns the table referenced by the single-column foreign key if it references its primary key. nil
+// otherwise.
+func (im *importer) target(fk *foreignKey) *table {
+	if len(fk.columns) != 1 {
+		return nil
+	}
+	t := im.table(fk.table)
+	if t == nil {
+		return nil
+	}
+	pk := im.primaryKey(t)
+	if pk == nil || len(fk.refColumns) > 1 || len(fk.refColumns) == 1 && !strings.EqualFold(fk.refColumns[0], pk.name) {
+		return nil
+	}
+	return t
+}
+
+// edgeKey returns the foreign key of the table that becomes an edge and is stored in the given column. nil if the
+// column is no such foreign key.
+func (im *importer) edgeKey(t *table, col string) *foreignKey {
+	if pk := im.primaryKey(t); pk != nil && strings.EqualFold(pk.name, col) {
+		return nil
+	}
+	for _, fk := range t.fks {
+		if len(fk.columns) == 1 && strings.EqualFold(fk.columns[0], col) {
+			if rt := im.target(fk); rt != nil && im.schemas[rt] != nil {
+				return fk
+			}
+		}
+	}
+	return nil
+}
+
+// fields adds the columns of the table that are neither primary nor foreign keys to the schema.
+func (im *importer) fields(t *table, s *SpecSchema) {
+	pk := im.primaryKey(t)
+	for _, c := range t.columns {
+		if c == pk || im.edgeKey(t, c.name) != nil {
+			continue
+		}
+		if fk := im.foreignKeyOf(t, c.name); fk != nil {
+			im.notef("%s.%s: the foreign key to %s cannot be expressed as edge, the column is imported as field", t.name, c.name, fk.table)
+		}
+		f := im.field(t, c)
+		s.Fields = append(s.Fields, f)
+	}
+}
+
+// foreignKeyOf returns a foreign key of the table including the column. nil if there is none.
+func (im *importer) foreignKeyOf(t *table, col string) *foreignKey {
+	for _, fk := range t.fks {
+		if containsFold(fk.columns, col) {
+			return fk
+		}
+	}
+	return nil
+}
+
+// field returns the field of the column.
+func (im *importer) field(t *table, c *column) *SpecField {
+	f := &SpecField{Name: c.name, Comment: c.comment}
+	if !fieldNameRgx.MatchString(f.Name) {
+		f.Name = strings.Trim(invalidNameRgx.ReplaceAllString(c.name, "_"), "_0123456789")
+		if f.Name == "" {
+			f.Name = "field"
+		}
+	}
+	if f.Name = uniqueName(im.names[im.schemas[t]], f.Name); f.Name != c.name {
+		f.StorageKey = c.name
+	}
+	typ, st := im.fieldType(c)
+	f.Type = typ
+	if st {
+		f.SchemaType = map[string]string{im.dialect: c.raw}
+	}
+	switch {
+	case c.array:
+		f.Type, f.GoType, f.SchemaType = "json", "[]"+goType(typ), map[string]string{im.dialect: c.raw}
+	case typ == "json":
+		f.GoType = "map[string]interface{}"
+	case typ == "enum":
+		vs := c.args
+		if c.typ != "enum" {
+			vs = im.enums[c.typ]
+		}
+		if f.Values = enumSpecValues(vs); f.Values == nil {
+			im.notef("%s.%s: the enum values cannot be used as Go identifiers, the column is imported as string", t.name, c.name)
+			f.Type, f.SchemaType = "string", map[string]string{im.dialect: c.raw}
+		}
+	case typ == "string" && c.typ == "":
+		im.notef("%s.%s: the column has no type, it is imported as string", t.name, c.name)
+	case typ == "string" && st && !strings.Contains(c.typ, "char") && c.typ != "set":
+		im.notef("%s.%s: ent has no type for %s, the column is imported as string", t.name, c.name, c.raw)
+	}
+	if !c.notNull && !c.pk {
+		f.Optional, f.Nillable = true, hasNillable(f.Type)
+	}
+	if c.unique {
+		setUnique(im.schemas[t], f)
+	}
+	if c.hasDef {
+		if d, ok := defaultOf(f.Type, c); ok {
+			f.Default = d
+		} else {
+			im.notef("%s.%s: the default %s cannot be expressed in Go, set it with an entsql annotation", t.name, c.name, c.def)
+		}
+	}
+	if c.updateNow && f.Type == "time" {
+		f.UpdateDefault = true
+	}
+	return f
+}
+
+// fieldType returns the wizard type of the column and whether its type must be kept as schema type.
+func (im *importer) fieldType(c *column) (string, bool) {
+	sized := len(c.args) > 0
+	integer := func(typ string) string {
+		if c.unsigned {
+			return "u" + typ
+		}
+		return typ
+	}
+	switch c.typ {
+	case "bool", "boolean":
+		return "bool", false
+	case "bit":
+		if !sized || c.args[0] == "1" {
+			return "bool", false
+		}
+		return "uint64", true
+	case "tinyint":
+		if sized && c.args[0] == "1" {
+			return "bool", false
+		}
+		return integer("int8"), false
+	case "smallint", "int2", "smallserial":
+		return integer("int16"), false
+	case "mediumint":
+		return integer("int32"), false
+	case "int", "integer", "int4", "serial":
+		return integer("int"), false
+	case "bigint", "int8", "bigserial":
+		return integer("int64"), false
+	case "real", "float4":
+		if im.dialect == "sqlite3" {
+			return "float", false
+		}
+		return "float32", false
+	case "float":
+		if p, err := strconv.Atoi(strings.Join(c.args, "")); err == nil && p > 24 {
+			return "float", false
+		}
+		return "float32", false
+	case "double", "double precision", "float8":
+		return "float", false
+	case "decimal", "numeric", "dec", "fixed", "money", "number":
+		return "float", true
+	case "varchar", "character varying", "nvarchar", "varchar2", "nvarchar2":
+		return "string", sized && c.args[0] != "255"
+	case "char", "character", "nchar", "citext":
+		return "string", sized || c.typ == "citext"
+	case "text", "longtext", "clob", "ntext":
+		return "text", false
+	case "tinytext", "mediumtext":
+		return "text", true
+	case "blob", "longblob", "bytea":
+		return "[]byte", false
+	case "tinyblob", "mediumblob", "binary", "varbinary", "image", "raw":
+		return "[]byte", true
+	case "timestamp", "timestamp without time zone":
+		return "time", sized || im.dialect == "postgres"
+	case "timestamptz", "timestamp with time zone":
+		return "time", sized
+	case "datetime":
+		return "time", sized || im.dialect != "sqlite3"
+	case "date", "time", "timetz", "time with time zone", "time without time zone", "year":
+		return "time", true
+	case "uuid", "uniqueidentifier":
+		return "uuid", false
+	case "json", "jsonb":
+		return "json", c.typ == "jsonb" && im.dialect != "postgres"
+	case "enum":
+		return "enum", false
+	case "":
+		return "string", false
+	}
+	if _, ok := im.enums[c.typ]; ok {
+		return "enum", false
+	}
+	return "string", true
+}
+
+// goType returns the Go type of values of the wizard type.
+func goType(typ string) string {
+	switch typ {
+	case "string", "text", "enum", "uuid":
+		return "string"
+	case "float":
+		return "float64"
+	case "time":
+		return "time.Time"
+	case "[]byte", "json":
+		return "json.RawMessage"
+	}
+	return typ
+}
+
+// enumSpecValues returns the values of an enum column in the format of SpecField.Values. Values that are no Go
+// identifiers are named after them. nil if the values cannot be named.
+func enumSpecValues(vs []string) []string {
+	if len(vs) == 0 {
+		return nil
+	}
+	out := make([]string, len(vs))
+	seen := make(map[string]bool)
+	for i, v := range vs {
+		n := v
+		if !enumNameRgx.MatchString(v) {
+			n = strings.Trim(invalidNameRgx.ReplaceAllString(v, "_"), "_")
+			if n == "" || !enumNameRgx.MatchString(n) {
+				return nil
+			}
+			out[i] = n + "=" + v
+		} else {
+			out[i] = v
+		}
+		if seen[n] {
+			return nil
+		}
+		seen[n] = true
+	}
+	return out
+}
+
+// defaultOf returns the default of the column as value of SpecField.Default. Reports whether it can be expressed.
+func defaultOf(typ string, c *column) (string, bool) {
+	v := c.def
+	if strings.EqualFold(v, "NULL") && !c.defString {
+		return "", true
+	}
+	switch typ {
+	case "time":
+		return "time.Now", isNow(v)
+	case "uuid":
+		switch strings.ToLower(v) {
+		case "gen_random_uuid()", "uuid_generate_v4()", "uuid()", "newid()":
+			return "uuid.New", true
+		}
+		return "", false
+	case "bool":
+		switch strings.ToLower(v) {
+		case "1", "true", "b'1'", "t", "y", "yes":
+			return "true", true
+		case "0", "false", "b'0'", "f", "n", "no":
+			return "false", true
+		}
+		return "", false
+	case "string", "text", "enum":
+		if !c.defString && strings.ContainsAny(v, "() ") {
+			return "", false
+		}
+	case "json", "[]byte":
+		return "", false
+	}
+	if _, err := DefaultExpr(typ, v); err != nil {
+		return "", false
+	}
+	return v, true
+}
+
+// edges adds the edges of the foreign keys of the table to its schema and the back-references to the schemas of the
+// referenced tables.
+func (im *importer) edges(t *table, s *SpecSchema) {
+	im.edgeNames[t] = make(map[string]string)
+	for _, c := range t.columns {
+		fk := im.edgeKey(t, c.name)
+		if fk == nil {
+			continue
+		}
+		rs := im.schemas[im.target(fk)]
+		name := uniqueName(im.names[s], strings.TrimSuffix(strings.TrimSuffix(invalidNameRgx.ReplaceAllString(c.name, "_"), "_id"), "_ID"))
+		// Name the back-reference after the edge if it would be ambiguous otherwise.
+		ref := tableName(s.Name)
+		if rs == s || im.references(t, fk.table) > 1 {
+			ref = name + "_" + ref
+		}
+		ref = uniqueName(im.names[rs], ref)
+		unique := c.unique || im.uniqueIndex(t, c.name)
+		im.edgeNames[t][c.name] = name
+		s.Edges = append(s.Edges, &SpecEdge{Name: name, Type: rs.Name, Ref: ref, Unique: true, Required: c.notNull})
+		rs.Edges = append(rs.Edges, &SpecEdge{Name: ref, Type: s.Name, Unique: unique, Columns: []string{c.name}})
+		if fk.onDelete != "" {
+			im.notef("%s.%s: set the action ON DELETE %s with an entsql annotation on edge %s of %s", t.name, c.name, fk.onDelete, ref, rs.Name)
+		}
+	}
+}
+
+// references returns the number of foreign keys of the table referencing the other table.
+func (im *importer) references(t *table, other string) int {
+	n := 0
+	for _, fk := range t.fks {
+		if strings.EqualFold(fk.table, other) && im.edgeKey(t, fk.columns[0]) != nil {
+			n++
+		}
+	}
+	return n
+}
+
+// uniqueIndex reports whether the table has a unique index on the column alone.
+func (im *importer) uniqueIndex(t *table, col string) bool {
+	for _, i := range t.indexes {
+		if i.unique && len(i.columns) == 1 && strings.EqualFold(i.columns[0], col) {
+			return true
+		}
+	}
+	return false
+}
+
+// uniqueName returns the name, suffixed by a number if names already holds it, and adds it to names. Empty names,
+// e.g. of edges named after a column called id, become edge.
+func uniqueName(names map[string]bool, name string) string {
+	if name == "" {
+		name = "edge"
+	}
+	n := name
+	for i := 2; names[n]; i++ {
+		n = fmt.Sprintf("%s%d", name, i)
+	}
+	names[n] = true
+	return n
+}
+
+// m2m adds the M2M edge of the join table to the schemas of the tables it references.
+func (im *importer) m2m(t *table) {
+	from, to := t.fks[0], t.fks[1]
+	fs, ts := im.schemas[im.target(from)], im.schemas[im.target(to)]
+	name := uniqueName(im.names[fs], pluralName(strings.TrimSuffix(to.columns[0], "_id")))
+	ref := uniqueName(im.names[ts], pluralName(strings.TrimSuffix(from.columns[0], "_id")))
+	fs.Edges = append(fs.Edges, &SpecEdge{Name: name, Type: ts.Name, Table: t.name, Columns: []string{from.columns[0], to.columns[0]}})
+	ts.Edges = append(ts.Edges, &SpecEdge{Name: ref, Type: fs.Name, Ref: name})
+}
+
+// indexes adds the indexes of the table to its schema. Columns of foreign keys are indexed by their edges.
+func (im *importer) indexes(t *table, s *SpecSchema) {
+	pk := im.primaryKey(t)
+Indexes:
+	for _, i := range t.indexes {
+		si := &SpecIndex{Unique: i.unique, StorageKey: i.name}
+		for _, col := range i.columns {
+			c := t.column(col)
+			switch {
+			case c == nil:
+				im.notef("%s: the index on unknown column %s is skipped", t.name, col)
+				continue Indexes
+			case c == pk:
+				// Indexes on the primary key are redundant.
+				continue Indexes
+			case im.edgeNames[t][c.name] != "":
+				si.Edges = append(si.Edges, im.edgeNames[t][c.name])
+			default:
+				si.Fields = append(si.Fields, im.fieldOf(s, c))
+			}
+		}
+		// Foreign keys are indexed anyway, unique ones are O2O edges.
+		if len(si.Fields) > 0 {
+			addIndex(s, si)
+		}
+	}
+}
+
+// fieldOf returns the name of the field of the column.
+func (im *importer) fieldOf(s *SpecSchema, c *column) string {
+	for _, f := range s.Fields {
+		if f.Name == c.name || f.StorageKey == c.name {
+			return f.Name
+		}
+	}
+	return c.name
+}
+
+// tableName returns the name ent derives for the table of the schema, e.g. pets for Pet.
+func tableName(schema string) string {
+	return gen.Type{Name: schema}.Table()
+}
+
+// pluralName returns the plural of the snake_case name, e.g. pets for pet.
+func pluralName(n string) string {
+	return tableName(pascal(n))
+}
+
+// snake returns the snake_case form of the Go name, e.g. owner_id for OwnerID.
+func snake(s string) string {
+	return gen.Funcs["snake"].(func(string) string)(s)
+}
+
+// pascal returns the PascalCase form of the snake_case name, e.g. OwnerID for owner_id.
+func pascal(s string) string {
+	return gen.Funcs["pascal"].(func(string) string)(s)
+}
+
+// singular returns the singular of the name, e.g. pet for pets.
+func singular(s string) string {
+	return gen.Funcs["singular"].(func(string) string)(s)
+}
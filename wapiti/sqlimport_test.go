@@ -0,0 +1,141 @@
+package wapiti
+
+import (
+	"entgo.io/ent/entc/load"
+	"github.com/stretchr/testify/require"
+	"testing"
+)
+
+func TestImportSQL(t *testing.T) {
+	sf, notes, err := ImportSQL(`
+CREATE TABLE users (
+	id bigint NOT NULL AUTO_INCREMENT,
+	name varchar(255) NOT NULL,
+	email varchar(128) NOT NULL COMMENT 'login',
+	active tinyint(1) NOT NULL DEFAULT '1',
+	role enum('admin','read-only') NOT NULL DEFAULT 'admin',
+	balance decimal(10,2) DEFAULT NULL,
+	created_at timestamp NOT NULL DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP,
+	PRIMARY KEY (id),
+	UNIQUE KEY users_email (email),
+	KEY users_name_created (name, created_at)
+) ENGINE=InnoDB;
+CREATE TABLE pets (
+	id int NOT NULL AUTO_INCREMENT PRIMARY KEY,
+	owner_id bigint NOT NULL,
+	CONSTRAINT pets_owner FOREIGN KEY (owner_id) REFERENCES users (id) ON DELETE CASCADE
+);
+CREATE TABLE tbl_group (id int NOT NULL PRIMARY KEY, name varchar(64));
+CREATE TABLE user_groups (
+	user_id bigint NOT NULL REFERENCES users (id),
+	group_id int NOT NULL REFERENCES tbl_group (id),
+	PRIMARY KEY (user_id, group_id)
+);
+`, "")
+	require.NoError(t, err)
+	require.Equal(t, []string{"pets.owner_id: set the action ON DELETE CASCADE with an entsql annotation on edge pets of User"}, notes)
+	require.Equal(t, &SpecFile{Schemas: []*SpecSchema{
+		{
+			Name: "User",
+			ID:   "int64",
+			Fields: []*SpecField{
+				{Name: "name", Type: "string"},
+				{Name: "email", Type: "string", Unique: true, SchemaType: map[string]string{"mysql": "varchar(128)"}, Comment: "login"},
+				{Name: "active", Type: "bool", Default: "true"},
+				{Name: "role", Type: "enum", Values: []string{"admin", "read_only=read-only"}, Default: "admin"},
+				{Name: "balance", Type: "float", Optional: true, Nillable: true, SchemaType: map[string]string{"mysql": "decimal(10, 2)"}},
+				{Name: "created_at", Type: "time", Default: "time.Now", UpdateDefault: true},
+			},
+			Edges: []*SpecEdge{
+				{Name: "pets", Type: "Pet", Columns: []string{"owner_id"}},
+				{Name: "groups", Type: "TblGroup", Table: "user_groups", Columns: []string{"user_id", "group_id"}},
+			},
+			Indexes: []*SpecIndex{{Fields: []string{"name", "created_at"}, StorageKey: "users_name_created"}},
+		},
+		{
+			Name:  "Pet",
+			Edges: []*SpecEdge{{Name: "owner", Type: "User", Ref: "pets", Unique: true, Required: true}},
+		},
+		{
+			Name:   "TblGroup",
+			Table:  "tbl_group",
+			Fields: []*SpecField{{Name: "name", Type: "string", Optional: true, Nillable: true, SchemaType: map[string]string{"mysql": "varchar(64)"}}},
+			Edges:  []*SpecEdge{{Name: "users", Type: "User", Ref: "groups"}},
+		},
+	}}, sf)
+
+	// The spec can be planned.
+	w := &Wapiti{spec: &load.SchemaSpec{}}
+	cs, err := w.Plan(sf)
+	require.NoError(t, err)
+	require.Equal(t, "+ create schema User (int64 id)", cs[0].String())
+	require.Equal(t, "~ set table of TblGroup to tbl_group", cs[3].String())
+}
+
+func TestImportSQLPostgres(t *testing.T) {
+	sf, notes, err := ImportSQL(`
+CREATE TYPE mood AS ENUM ('happy', 'sad');
+CREATE TABLE public.categories (
+	id uuid DEFAULT gen_random_uuid() NOT NULL,
+	"Title" character varying(64) NOT NULL,
+	parent_id uuid REFERENCES categories(id),
+	tags text[],
+	meta jsonb DEFAULT '{}'::jsonb,
+	mood mood DEFAULT 'happy'::mood NOT NULL,
+	location point,
+	CONSTRAINT categories_pkey PRIMARY KEY (id)
+);
+CREATE UNIQUE INDEX categories_title ON public.categories USING btree ("Title");
+CREATE TABLE logs (line text);
+`, "")
+	require.NoError(t, err)
+	require.Equal(t, []string{
+		"logs: the table has no primary key, ent adds an id column",
+		"categories.meta: the default {} cannot be expressed in Go, set it with an entsql annotation",
+		"categories.location: ent has no type for point, the column is imported as string",
+	}, notes)
+	require.Len(t, sf.Schemas, 2)
+	s := sf.Schemas[0]
+	require.Equal(t, "Category", s.Name)
+	require.Equal(t, "uuid", s.ID)
+	require.Empty(t, s.Table)
+	require.Equal(t, []*SpecField{
+		{Name: "Title", Type: "string", Unique: true, SchemaType: map[string]string{"postgres": "character varying(64)"}},
+		{Name: "tags", Type: "json", Optional: true, SchemaType: map[string]string{"postgres": "text[]"}, GoType: "[]string"},
+		{Name: "meta", Type: "json", Optional: true, GoType: "map[string]interface{}"},
+		{Name: "mood", Type: "enum", Values: []string{"happy", "sad"}, Default: "happy"},
+		{Name: "location", Type: "string", Optional: true, Nillable: true, SchemaType: map[string]string{"postgres": "point"}},
+	}, s.Fields)
+	require.Equal(t, []*SpecEdge{
+		{Name: "parent", Type: "Category", Ref: "parent_categories", Unique: true},
+		{Name: "parent_categories", Type: "Category", Columns: []string{"parent_id"}},
+	}, s.Edges)
+	require.Empty(t, s.Indexes)
+	require.Equal(t, &SpecSchema{Name: "Log", Fields: []*SpecField{{Name: "line", Type: "text", Optional: true, Nillable: true}}}, sf.Schemas[1])
+
+	// The fields can be written.
+	w := &Wapiti{spec: &load.SchemaSpec{}}
+	for _, sf := range s.Fields {
+		f, err := sf.field(w, &load.Schema{Name: s.Name})
+		require.NoError(t, err)
+		_, err = f.code()
+		require.NoError(t, err, sf.Name)
+	}
+
+	_, _, err = ImportSQL("CREATE TABLE t (id int)", "oracle")
+	require.Error(t, err)
+}
+
+func TestImportSQLDefaultExpressions(t *testing.T) {
+	sf, notes, err := ImportSQL("CREATE TABLE pets (id int PRIMARY KEY, age int NOT NULL DEFAULT 1+2, name text NOT NULL DEFAULT 'a' || 'b', legs int NOT NULL DEFAULT -4)", "postgres")
+	require.NoError(t, err)
+	require.Equal(t, []string{
+		"pets.age: the default 1 + 2 cannot be expressed in Go, set it with an entsql annotation",
+		"pets.name: the default 'a' || 'b' cannot be expressed in Go, set it with an entsql annotation",
+	}, notes)
+	require.Equal(t, []*SpecField{
+		{Name: "age", Type: "int"},
+		{Name: "name", Type: "text"},
+		{Name: "legs", Type: "int", Default: "-4"},
+	}, sf.Schemas[0].Fields)
+}
@@ -0,0 +1,336 @@
+package wapiti
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"math"
+	"os"
+	"strings"
+	"unicode/utf16"
+)
+
+// sqliteMagic is the header string every SQLite 3 database file starts with.
+const sqliteMagic = "SQLite format 3\x00"
+
+// ImportSQLite reads the statements creating the tables and indexes of the SQLite database file and returns the
+// SpecFile describing them like ImportSQL does. The file is read directly, no SQLite driver is needed.
+func ImportSQLite(path string) (*SpecFile, []string, error) {
+	stmts, err := sqliteSchema(path)
+	if err != nil {
+		return nil, nil, err
+	}
+	sf, notes, err := ImportSQL(strings.Join(stmts, ";\n"), "sqlite3")
+	if err != nil {
+		return nil, nil, err
+	}
+	// Changes still held by the write-ahead log are not visible in the database file.
+	if fi, err := os.Stat(path + "-wal"); err == nil && fi.Size() > 0 {
+		notes = append(notes, fmt.Sprintf("%s-wal is not empty, recent schema changes may be missing: checkpoint the database first", path))
+	}
+	return sf, notes, nil
+}
+
+// sqliteSchema returns the statements creating the tables and indexes of the SQLite database file in the order they
+// are stored in the schema table. Internal tables of SQLite are left out.
+func sqliteSchema(path string) ([]string, error) {
+	b, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("reading file %s: %w", path, err)
+	}
+	db, err := newSQLiteFile(b)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	var stmts []string
+	// The schema table is rooted at the first page. Its columns are type, name, tbl_name, rootpage and sql.
+	err = db.scan(1, func(rec []interface{}) error {
+		if len(rec) < 5 {
+			return errors.New("malformed schema table")
+		}
+		typ, _ := rec[0].(string)
+		name, _ := rec[1].(string)
+		sql, _ := rec[4].(string)
+		if (typ == "table" || typ == "index") && sql != "" && !strings.HasPrefix(name, "sqlite_") {
+			stmts = append(stmts, sql)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return stmts, nil
+}
+
+// sqliteFile is the contents of a SQLite database file.
+type sqliteFile struct {
+	data []byte
+	// pageSize is the size of the pages, usable the number of bytes of a page that hold data.
+	pageSize, usable int
+	// utf16 is the byte order of text values. nil for UTF-8 encoded databases.
+	utf16 binary.ByteOrder
+}
+
+// newSQLiteFile validates the header of the database file.
+func newSQLiteFile(b []byte) (*sqliteFile, error) {
+	if len(b) < 100 || string(b[:16]) != sqliteMagic {
+		return nil, errors.New("not a SQLite 3 database")
+	}
+	db := &sqliteFile{data: b, pageSize: int(binary.BigEndian.Uint16(b[16:18]))}
+	if db.pageSize == 1 {
+		db.pageSize = 65536
+	}
+	db.usable = db.pageSize - int(b[20])
+	switch enc := binary.BigEndian.Uint32(b[56:60]); enc {
+	case 0, 1:
+	case 2:
+		db.utf16 = binary.LittleEndian
+	case 3:
+		db.utf16 = binary.BigEndian
+	default:
+		return nil, fmt.Errorf("unknown text encoding %d", enc)
+	}
+	if db.pageSize < 512 || db.usable < 480 {
+		return nil, errors.New("invalid page size")
+	}
+	return db, nil
+}
+
+// page returns the page with the given number, starting at 1.
+func (db *sqliteFile) page(n int) ([]byte, error) {
+	off := (n - 1) * db.pageSize
+	if n < 1 || off+db.pageSize > len(db.data) {
+		return nil, fmt.Errorf("page %d out of range", n)
+	}
+	return db.data[off : off+db.pageSize], nil
+}
+
+// scan calls fn for every record of the table b-tree rooted at the given page in rowid order.
+func (db *sqliteFile) scan(root int, fn func([]interface{}) error) error {
+	return db.scanPage(root, fn, 0)
+}
+
+// scanPage scans the b-tree page. depth guards against cycles in corrupt files.
+func (db *sqliteFile) scanPage(n int, fn func([]interface{}) error, depth int) error {
+	if depth > 64 {
+		return errors.New("b-tree too deep")
+	}
+	p, err := db.page(n)
+	if err != nil {
+		return err
+	}
+	// The first page starts with the database header.
+	hdr := 0
+	if n == 1 {
+		hdr = 100
+	}
+	if hdr+8 > len(p) {
+		return fmt.Errorf("page %d: truncated header", n)
+	}
+	cells := int(binary.BigEndian.Uint16(p[hdr+3 : hdr+5]))
+	switch p[hdr] {
+	case 0x05:
+		// Interior page: the cells point to the children left of the key, the right-most child follows.
+		ptrs := hdr + 12
+		for i := 0; i < cells; i++ {
+			off, err := cellOffset(p, ptrs, i)
+			if err != nil {
+				return fmt.Errorf("page %d: %w", n, err)
+			}
+			if off+4 > len(p) {
+				return fmt.Errorf("page %d: cell out of range", n)
+			}
+			if err := db.scanPage(int(binary.BigEndian.Uint32(p[off:off+4])), fn, depth+1); err != nil {
+				return err
+			}
+		}
+		return db.scanPage(int(binary.BigEndian.Uint32(p[hdr+8:hdr+12])), fn, depth+1)
+	case 0x0d:
+		ptrs := hdr + 8
+		for i := 0; i < cells; i++ {
+			off, err := cellOffset(p, ptrs, i)
+			if err != nil {
+				return fmt.Errorf("page %d: %w", n, err)
+			}
+			payload, err := db.payload(p, off)
+			if err != nil {
+				return fmt.Errorf("page %d: %w", n, err)
+			}
+			rec, err := db.record(payload)
+			if err != nil {
+				return fmt.Errorf("page %d: %w", n, err)
+			}
+			if err := fn(rec); err != nil {
+				return err
+			}
+		}
+		return nil
+	default:
+		return fmt.Errorf("page %d: not a table b-tree page", n)
+	}
+}
+
+// cellOffset returns the offset of the i-th cell of the page whose cell pointers start at ptrs.
+func cellOffset(p []byte, ptrs, i int) (int, error) {
+	if ptrs+2*i+2 > len(p) {
+		return 0, errors.New("cell pointer out of range")
+	}
+	off := int(binary.BigEndian.Uint16(p[ptrs+2*i:]))
+	if off >= len(p) {
+		return 0, errors.New("cell out of range")
+	}
+	return off, nil
+}
+
+// payload returns the payload of the leaf cell at the given offset of the page, following overflow pages.
+func (db *sqliteFile) payload(p []byte, off int) ([]byte, error) {
+	size, n := uvarint(p[off:])
+	if n == 0 {
+		return nil, errors.New("malformed cell")
+	}
+	off += n
+	// Skip the rowid.
+	if _, n = uvarint(p[off:]); n == 0 {
+		return nil, errors.New("malformed cell")
+	}
+	off += n
+	total := int(size)
+	if total < 0 || total > len(db.data) {
+		return nil, errors.New("malformed cell")
+	}
+	// The number of payload bytes stored on the page itself, see the file format documentation.
+	local, max := total, db.usable-35
+	if total > max {
+		min := (db.usable-12)*32/255 - 23
+		local = min + (total-min)%(db.usable-4)
+		if local > max {
+			local = min
+		}
+	}
+	if off+local > len(p) {
+		return nil, errors.New("cell out of range")
+	}
+	out := append(make([]byte, 0, total), p[off:off+local]...)
+	off += local
+	if local == total {
+		return out, nil
+	}
+	if off+4 > len(p) {
+		return nil, errors.New("cell out of range")
+	}
+	next := int(binary.BigEndian.Uint32(p[off:]))
+	for pages := 0; len(out) < total; pages++ {
+		if pages > len(db.data)/db.pageSize {
+			return nil, errors.New("overflow chain too long")
+		}
+		op, err := db.page(next)
+		if err != nil {
+			return nil, err
+		}
+		n := total - len(out)
+		if n > db.usable-4 {
+			n = db.usable - 4
+		}
+		out = append(out, op[4:4+n]...)
+		next = int(binary.BigEndian.Uint32(op[:4]))
+	}
+	return out, nil
+}
+
+// record decodes the values of the record. NULLs are nil, integers int64, reals float64, texts string and blobs
+// []byte.
+func (db *sqliteFile) record(b []byte) ([]interface{}, error) {
+	hsize, n := uvarint(b)
+	if n == 0 || int(hsize) > len(b) || int(hsize) < n {
+		return nil, errors.New("malformed record")
+	}
+	var types []uint64
+	for off := n; off < int(hsize); {
+		t, n := uvarint(b[off:hsize])
+		if n == 0 {
+			return nil, errors.New("malformed record")
+		}
+		types = append(types, t)
+		off += n
+	}
+	vs := make([]interface{}, len(types))
+	body := b[hsize:]
+	for i, t := range types {
+		var size int
+		switch {
+		case t <= 4:
+			size = int(t)
+		case t == 5:
+			size = 6
+		case t == 6, t == 7:
+			size = 8
+		case t == 8, t == 9:
+		case t >= 12:
+			size = int(t-12) / 2
+		default:
+			return nil, fmt.Errorf("unknown serial type %d", t)
+		}
+		// Serial types above the maximum int overflow the size.
+		if size < 0 || size > len(body) {
+			return nil, errors.New("malformed record")
+		}
+		v := body[:size]
+		body = body[size:]
+		switch {
+		case t == 0:
+		case t <= 6:
+			// Big-endian two's complement integers of 1, 2, 3, 4, 6 or 8 bytes.
+			var x int64
+			if v[0]&0x80 != 0 {
+				x = -1
+			}
+			for _, c := range v {
+				x = x<<8 | int64(c)
+			}
+			vs[i] = x
+		case t == 7:
+			vs[i] = math.Float64frombits(binary.BigEndian.Uint64(v))
+		case t == 8:
+			vs[i] = int64(0)
+		case t == 9:
+			vs[i] = int64(1)
+		case t%2 == 0:
+			vs[i] = append([]byte(nil), v...)
+		default:
+			vs[i] = db.text(v)
+		}
+	}
+	return vs, nil
+}
+
+// text decodes a text value in the encoding of the database.
+func (db *sqliteFile) text(b []byte) string {
+	if db.utf16 == nil {
+		return string(b)
+	}
+	u := make([]uint16, len(b)/2)
+	for i := range u {
+		u[i] = db.utf16.Uint16(b[2*i:])
+	}
+	return string(utf16.Decode(u))
+}
+
+// uvarint decodes the variable-length integer of SQLite at the start of b and returns it together with the number of
+// bytes read. 0 bytes are read if b is too short.
+func uvarint(b []byte) (uint64, int) {
+	var v uint64
+	for i := 0; i < 9; i++ {
+		if i >= len(b) {
+			return 0, 0
+		}
+		if i == 8 {
+			return v<<8 | uint64(b[i]), 9
+		}
+		v = v<<7 | uint64(b[i]&0x7f)
+		if b[i]&0x80 == 0 {
+			return v, i + 1
+		}
+	}
+	return v, 9
+}
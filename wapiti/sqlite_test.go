@@ -0,0 +1,84 @@
+package wapiti
+
+import (
+	"github.com/stretchr/testify/require"
+	"io/ioutil"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// testdata/app.db is generated from testdata/app.sql. It uses pages of 512 bytes so that the schema table spans
+// several pages and the statement creating the table wide overflows its page.
+func TestImportSQLite(t *testing.T) {
+	stmts, err := sqliteSchema("testdata/app.db")
+	require.NoError(t, err)
+	require.Len(t, stmts, 44)
+	require.True(t, strings.HasPrefix(stmts[0], "CREATE TABLE users ("))
+	require.Equal(t, "CREATE INDEX pet_name ON pet (name)", stmts[2])
+	require.True(t, strings.HasPrefix(stmts[3], "CREATE TABLE wide ("))
+	require.True(t, strings.HasSuffix(stmts[3], "c159 INTEGER DEFAULT 159)"))
+	require.Equal(t, "CREATE TABLE tag_bb (id INTEGER PRIMARY KEY, v TEXT)", stmts[4])
+
+	sf, notes, err := ImportSQLite("testdata/app.db")
+	require.NoError(t, err)
+	require.Len(t, notes, 1)
+	require.Len(t, sf.Schemas, 43)
+	u, p, wide := sf.Schemas[0], sf.Schemas[1], sf.Schemas[2]
+	require.Equal(t, "User", u.Name)
+	require.Empty(t, u.Table)
+	require.Equal(t, "Email", u.Fields[1].Name)
+	require.True(t, u.Fields[1].Unique)
+	require.Equal(t, "VARCHAR(64)", u.Fields[1].SchemaType["sqlite3"])
+	require.Equal(t, []string{"owner_id"}, u.Edges[0].Columns)
+	require.Equal(t, "Pet", p.Name)
+	require.Equal(t, "pet", p.Table)
+	require.Equal(t, &SpecEdge{Name: "owner", Type: "User", Ref: "pets", Unique: true, Required: true}, p.Edges[0])
+	require.Equal(t, "pet_name", p.Indexes[0].StorageKey)
+	require.Len(t, wide.Fields, 159)
+	require.Equal(t, "159", wide.Fields[158].Default)
+}
+
+func TestImportSQLiteErrors(t *testing.T) {
+	f := filepath.Join(t.TempDir(), "app.db")
+	require.NoError(t, ioutil.WriteFile(f, []byte(strings.Repeat("CREATE TABLE t (id int);", 10)), 0644))
+	_, _, err := ImportSQLite(f)
+	require.EqualError(t, err, f+": not a SQLite 3 database")
+
+	b, err := ioutil.ReadFile("testdata/app.db")
+	require.NoError(t, err)
+	require.NoError(t, ioutil.WriteFile(f, b[:1024], 0644))
+	_, _, err = ImportSQLite(f)
+	require.Error(t, err)
+	require.Contains(t, err.Error(), "out of range")
+}
+
+func TestSQLiteRecord(t *testing.T) {
+	for _, tt := range []struct {
+		b []byte
+		v uint64
+		n int
+	}{
+		{[]byte{0x05}, 5, 1},
+		{[]byte{0x81, 0x00}, 128, 2},
+		{[]byte{0x82, 0x80, 0x01}, 0x8001, 3},
+		{[]byte{0x81}, 0, 0},
+		{[]byte{0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff}, 1<<64 - 1, 9},
+	} {
+		v, n := uvarint(tt.b)
+		require.Equal(t, tt.v, v)
+		require.Equal(t, tt.n, n)
+	}
+
+	db := &sqliteFile{}
+	// NULL, int8 -2, int16 300, zero, one, text "ab" and blob 0xff.
+	rec, err := db.record([]byte{8, 0, 1, 2, 8, 9, 17, 14, 0xfe, 0x01, 0x2c, 'a', 'b', 0xff})
+	require.NoError(t, err)
+	require.Equal(t, []interface{}{nil, int64(-2), int64(300), int64(0), int64(1), "ab", []byte{0xff}}, rec)
+	_, err = db.record([]byte{3, 1, 17, 0x01})
+	require.EqualError(t, err, "malformed record")
+	_, err = db.record([]byte{10, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff})
+	require.EqualError(t, err, "malformed record")
+	_, err = db.record([]byte{2, 10})
+	require.EqualError(t, err, "unknown serial type 10")
+}
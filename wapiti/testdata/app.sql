@@ -0,0 +1,206 @@
+-- app.db is generated from this file with: rm -f app.db && sqlite3 app.db < app.sql
+-- The small page size makes the schema table span several pages and the statement creating wide overflow its page.
+PRAGMA page_size=512;
+CREATE TABLE users (id INTEGER PRIMARY KEY AUTOINCREMENT, name TEXT NOT NULL, Email VARCHAR(64) UNIQUE, created_at DATETIME DEFAULT CURRENT_TIMESTAMP);
+CREATE TABLE pet (id INTEGER PRIMARY KEY, name TEXT NOT NULL, owner_id INTEGER NOT NULL REFERENCES users(id) ON DELETE CASCADE);
+CREATE INDEX pet_name ON pet (name);
+CREATE TABLE wide (id INTEGER PRIMARY KEY,
+  c1 INTEGER DEFAULT 1,
+  c2 INTEGER DEFAULT 2,
+  c3 INTEGER DEFAULT 3,
+  c4 INTEGER DEFAULT 4,
+  c5 INTEGER DEFAULT 5,
+  c6 INTEGER DEFAULT 6,
+  c7 INTEGER DEFAULT 7,
+  c8 INTEGER DEFAULT 8,
+  c9 INTEGER DEFAULT 9,
+  c10 INTEGER DEFAULT 10,
+  c11 INTEGER DEFAULT 11,
+  c12 INTEGER DEFAULT 12,
+  c13 INTEGER DEFAULT 13,
+  c14 INTEGER DEFAULT 14,
+  c15 INTEGER DEFAULT 15,
+  c16 INTEGER DEFAULT 16,
+  c17 INTEGER DEFAULT 17,
+  c18 INTEGER DEFAULT 18,
+  c19 INTEGER DEFAULT 19,
+  c20 INTEGER DEFAULT 20,
+  c21 INTEGER DEFAULT 21,
+  c22 INTEGER DEFAULT 22,
+  c23 INTEGER DEFAULT 23,
+  c24 INTEGER DEFAULT 24,
+  c25 INTEGER DEFAULT 25,
+  c26 INTEGER DEFAULT 26,
+  c27 INTEGER DEFAULT 27,
+  c28 INTEGER DEFAULT 28,
+  c29 INTEGER DEFAULT 29,
+  c30 INTEGER DEFAULT 30,
+  c31 INTEGER DEFAULT 31,
+  c32 INTEGER DEFAULT 32,
+  c33 INTEGER DEFAULT 33,
+  c34 INTEGER DEFAULT 34,
+  c35 INTEGER DEFAULT 35,
+  c36 INTEGER DEFAULT 36,
+  c37 INTEGER DEFAULT 37,
+  c38 INTEGER DEFAULT 38,
+  c39 INTEGER DEFAULT 39,
+  c40 INTEGER DEFAULT 40,
+  c41 INTEGER DEFAULT 41,
+  c42 INTEGER DEFAULT 42,
+  c43 INTEGER DEFAULT 43,
+  c44 INTEGER DEFAULT 44,
+  c45 INTEGER DEFAULT 45,
+  c46 INTEGER DEFAULT 46,
+  c47 INTEGER DEFAULT 47,
+  c48 INTEGER DEFAULT 48,
+  c49 INTEGER DEFAULT 49,
+  c50 INTEGER DEFAULT 50,
+  c51 INTEGER DEFAULT 51,
+  c52 INTEGER DEFAULT 52,
+  c53 INTEGER DEFAULT 53,
+  c54 INTEGER DEFAULT 54,
+  c55 INTEGER DEFAULT 55,
+  c56 INTEGER DEFAULT 56,
+  c57 INTEGER DEFAULT 57,
+  c58 INTEGER DEFAULT 58,
+  c59 INTEGER DEFAULT 59,
+  c60 INTEGER DEFAULT 60,
+  c61 INTEGER DEFAULT 61,
+  c62 INTEGER DEFAULT 62,
+  c63 INTEGER DEFAULT 63,
+  c64 INTEGER DEFAULT 64,
+  c65 INTEGER DEFAULT 65,
+  c66 INTEGER DEFAULT 66,
+  c67 INTEGER DEFAULT 67,
+  c68 INTEGER DEFAULT 68,
+  c69 INTEGER DEFAULT 69,
+  c70 INTEGER DEFAULT 70,
+  c71 INTEGER DEFAULT 71,
+  c72 INTEGER DEFAULT 72,
+  c73 INTEGER DEFAULT 73,
+  c74 INTEGER DEFAULT 74,
+  c75 INTEGER DEFAULT 75,
+  c76 INTEGER DEFAULT 76,
+  c77 INTEGER DEFAULT 77,
+  c78 INTEGER DEFAULT 78,
+  c79 INTEGER DEFAULT 79,
+  c80 INTEGER DEFAULT 80,
+  c81 INTEGER DEFAULT 81,
+  c82 INTEGER DEFAULT 82,
+  c83 INTEGER DEFAULT 83,
+  c84 INTEGER DEFAULT 84,
+  c85 INTEGER DEFAULT 85,
+  c86 INTEGER DEFAULT 86,
+  c87 INTEGER DEFAULT 87,
+  c88 INTEGER DEFAULT 88,
+  c89 INTEGER DEFAULT 89,
+  c90 INTEGER DEFAULT 90,
+  c91 INTEGER DEFAULT 91,
+  c92 INTEGER DEFAULT 92,
+  c93 INTEGER DEFAULT 93,
+  c94 INTEGER DEFAULT 94,
+  c95 INTEGER DEFAULT 95,
+  c96 INTEGER DEFAULT 96,
+  c97 INTEGER DEFAULT 97,
+  c98 INTEGER DEFAULT 98,
+  c99 INTEGER DEFAULT 99,
+  c100 INTEGER DEFAULT 100,
+  c101 INTEGER DEFAULT 101,
+  c102 INTEGER DEFAULT 102,
+  c103 INTEGER DEFAULT 103,
+  c104 INTEGER DEFAULT 104,
+  c105 INTEGER DEFAULT 105,
+  c106 INTEGER DEFAULT 106,
+  c107 INTEGER DEFAULT 107,
+  c108 INTEGER DEFAULT 108,
+  c109 INTEGER DEFAULT 109,
+  c110 INTEGER DEFAULT 110,
+  c111 INTEGER DEFAULT 111,
+  c112 INTEGER DEFAULT 112,
+  c113 INTEGER DEFAULT 113,
+  c114 INTEGER DEFAULT 114,
+  c115 INTEGER DEFAULT 115,
+  c116 INTEGER DEFAULT 116,
+  c117 INTEGER DEFAULT 117,
+  c118 INTEGER DEFAULT 118,
+  c119 INTEGER DEFAULT 119,
+  c120 INTEGER DEFAULT 120,
+  c121 INTEGER DEFAULT 121,
+  c122 INTEGER DEFAULT 122,
+  c123 INTEGER DEFAULT 123,
+  c124 INTEGER DEFAULT 124,
+  c125 INTEGER DEFAULT 125,
+  c126 INTEGER DEFAULT 126,
+  c127 INTEGER DEFAULT 127,
+  c128 INTEGER DEFAULT 128,
+  c129 INTEGER DEFAULT 129,
+  c130 INTEGER DEFAULT 130,
+  c131 INTEGER DEFAULT 131,
+  c132 INTEGER DEFAULT 132,
+  c133 INTEGER DEFAULT 133,
+  c134 INTEGER DEFAULT 134,
+  c135 INTEGER DEFAULT 135,
+  c136 INTEGER DEFAULT 136,
+  c137 INTEGER DEFAULT 137,
+  c138 INTEGER DEFAULT 138,
+  c139 INTEGER DEFAULT 139,
+  c140 INTEGER DEFAULT 140,
+  c141 INTEGER DEFAULT 141,
+  c142 INTEGER DEFAULT 142,
+  c143 INTEGER DEFAULT 143,
+  c144 INTEGER DEFAULT 144,
+  c145 INTEGER DEFAULT 145,
+  c146 INTEGER DEFAULT 146,
+  c147 INTEGER DEFAULT 147,
+  c148 INTEGER DEFAULT 148,
+  c149 INTEGER DEFAULT 149,
+  c150 INTEGER DEFAULT 150,
+  c151 INTEGER DEFAULT 151,
+  c152 INTEGER DEFAULT 152,
+  c153 INTEGER DEFAULT 153,
+  c154 INTEGER DEFAULT 154,
+  c155 INTEGER DEFAULT 155,
+  c156 INTEGER DEFAULT 156,
+  c157 INTEGER DEFAULT 157,
+  c158 INTEGER DEFAULT 158,
+  c159 INTEGER DEFAULT 159);
+CREATE TABLE tag_bb (id INTEGER PRIMARY KEY, v TEXT);
+CREATE TABLE tag_bc (id INTEGER PRIMARY KEY, v TEXT);
+CREATE TABLE tag_bd (id INTEGER PRIMARY KEY, v TEXT);
+CREATE TABLE tag_bf (id INTEGER PRIMARY KEY, v TEXT);
+CREATE TABLE tag_bg (id INTEGER PRIMARY KEY, v TEXT);
+CREATE TABLE tag_bk (id INTEGER PRIMARY KEY, v TEXT);
+CREATE TABLE tag_bm (id INTEGER PRIMARY KEY, v TEXT);
+CREATE TABLE tag_cb (id INTEGER PRIMARY KEY, v TEXT);
+CREATE TABLE tag_cc (id INTEGER PRIMARY KEY, v TEXT);
+CREATE TABLE tag_cd (id INTEGER PRIMARY KEY, v TEXT);
+CREATE TABLE tag_cf (id INTEGER PRIMARY KEY, v TEXT);
+CREATE TABLE tag_cg (id INTEGER PRIMARY KEY, v TEXT);
+CREATE TABLE tag_ck (id INTEGER PRIMARY KEY, v TEXT);
+CREATE TABLE tag_cm (id INTEGER PRIMARY KEY, v TEXT);
+CREATE TABLE tag_db (id INTEGER PRIMARY KEY, v TEXT);
+CREATE TABLE tag_dc (id INTEGER PRIMARY KEY, v TEXT);
+CREATE TABLE tag_dd (id INTEGER PRIMARY KEY, v TEXT);
+CREATE TABLE tag_df (id INTEGER PRIMARY KEY, v TEXT);
+CREATE TABLE tag_dg (id INTEGER PRIMARY KEY, v TEXT);
+CREATE TABLE tag_dk (id INTEGER PRIMARY KEY, v TEXT);
+CREATE TABLE tag_dm (id INTEGER PRIMARY KEY, v TEXT);
+CREATE TABLE tag_fb (id INTEGER PRIMARY KEY, v TEXT);
+CREATE TABLE tag_fc (id INTEGER PRIMARY KEY, v TEXT);
+CREATE TABLE tag_fd (id INTEGER PRIMARY KEY, v TEXT);
+CREATE TABLE tag_ff (id INTEGER PRIMARY KEY, v TEXT);
+CREATE TABLE tag_fg (id INTEGER PRIMARY KEY, v TEXT);
+CREATE TABLE tag_fk (id INTEGER PRIMARY KEY, v TEXT);
+CREATE TABLE tag_fm (id INTEGER PRIMARY KEY, v TEXT);
+CREATE TABLE tag_gb (id INTEGER PRIMARY KEY, v TEXT);
+CREATE TABLE tag_gc (id INTEGER PRIMARY KEY, v TEXT);
+CREATE TABLE tag_gd (id INTEGER PRIMARY KEY, v TEXT);
+CREATE TABLE tag_gf (id INTEGER PRIMARY KEY, v TEXT);
+CREATE TABLE tag_gg (id INTEGER PRIMARY KEY, v TEXT);
+CREATE TABLE tag_gk (id INTEGER PRIMARY KEY, v TEXT);
+CREATE TABLE tag_gm (id INTEGER PRIMARY KEY, v TEXT);
+CREATE TABLE tag_kb (id INTEGER PRIMARY KEY, v TEXT);
+CREATE TABLE tag_kc (id INTEGER PRIMARY KEY, v TEXT);
+CREATE TABLE tag_kd (id INTEGER PRIMARY KEY, v TEXT);
+CREATE TABLE tag_kf (id INTEGER PRIMARY KEY, v TEXT);
+CREATE TABLE tag_kg (id INTEGER PRIMARY KEY, v TEXT);
@@ -0,0 +1,72 @@
+package models
+
+import (
+	"database/sql"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+type Role string
+
+// Base is embedded by the models.
+type Base struct {
+	ID        uuid.UUID `gorm:"type:uuid;primaryKey"`
+	CreatedAt time.Time
+}
+
+type User struct {
+	gorm.Model
+	Name      string                 `gorm:"size:64;not null" json:"name"`
+	Email     string                 `gorm:"uniqueIndex" json:"email"`
+	Nickname  *string                `json:"nick,omitempty"`
+	Role      Role                   `gorm:"default:'member'"`
+	Bio       string                 `gorm:"type:text"`
+	Active    bool                   `gorm:"default:true"`
+	Settings  map[string]interface{} `gorm:"serializer:json"`
+	Pets      []Pet                  `gorm:"foreignKey:OwnerID"`
+	Profile   Profile
+	Languages []*Language `gorm:"many2many:user_languages"`
+	Friends   []*User     `gorm:"many2many:user_friends"`
+	secret    string
+	Ignored   string `gorm:"-"`
+}
+
+type Profile struct {
+	ID     uint
+	UserID uint
+	Bio    sql.NullString
+}
+
+type Pet struct {
+	ID      uint
+	Name    string `gorm:"index:idx_pet_name_owner"`
+	OwnerID uint   `gorm:"index:idx_pet_name_owner"`
+	Owner   User
+	Address Address   `gorm:"embedded;embeddedPrefix:addr_"`
+	Born    time.Time `gorm:"autoCreateTime"`
+	Tags    []string  `gorm:"serializer:json"`
+	Extra   *[]string `gorm:"serializer:json"`
+	Data    []byte
+}
+
+type Address struct {
+	Street string
+	City   string `gorm:"column:town"`
+}
+
+type Language struct {
+	Code  string  `gorm:"primaryKey;size:2"`
+	Users []*User `gorm:"many2many:user_languages"`
+}
+
+func (Language) TableName() string { return "lang" }
+
+type Session struct {
+	Base
+	Token  string `db:"token_value"`
+	Expiry sql.NullTime
+	UserID *uint
+	User   *User
+}
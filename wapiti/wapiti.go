@@ -2,6 +2,7 @@ package wapiti
 
 import (
 	"entgo.io/ent/entc/load"
+	"errors"
 	"fmt"
 	"github.com/logrusorgru/aurora/v3"
 	"github.com/masseelch/wapiti/wapiti/config"
@@ -9,6 +10,20 @@ import (
 	"go/parser"
 	"go/token"
 	"path/filepath"
+	"strings"
+)
+
+const (
+	fieldAddedFormat   = "\nadded field %s to %s\n"
+	fieldUpdatedFormat = "\nupdated field %s of %s\n"
+	fieldRemovedFormat = "\nremoved field %s from %s\n"
+	edgeAddedFormat    = "\nadded edge %s to %s\n"
+	mixinAddedFormat   = "\nadded mixin %s to %s%s\n"
+	hookAddedFormat    = "\nadded hook on %s to %s\n"
+	policyAddedFormat  = "\nadded policy to %s\n"
+	indexAddedFormat   = "\nadded index on %s to %s\n"
+	annotatedFormat    = "\nannotated %s\n"
+	notWrittenMsg      = "\nchange was not written"
 )
 
 type Wapiti struct {
@@ -16,6 +31,10 @@ type Wapiti struct {
 	spec *load.SchemaSpec
 	fset *token.FileSet
 	ast  *ast.Package
+	// interactive is set while the wizard runs and makes wapiti ask for confirmation before writing a file.
+	interactive bool
+	// staged holds the contents of the files changed by stage, keyed by file name. nil if no changes are staged.
+	staged map[string][]byte
 }
 
 func New(cfg *config.Config) (*Wapiti, error) {
@@ -50,23 +69,128 @@ func (w *Wapiti) Reload() error {
 
 // Run runs the interactive cli, asking questions how to change the schema.
 func (w *Wapiti) Run() error {
+	w.interactive = true
+	defer func() { w.interactive = false }()
 	// Select the node to edit.
 	n, err := w.SelectNode()
+	if w.skip(err) {
+		return nil
+	}
 	if err != nil {
 		return fmt.Errorf(aurora.Red("ERROR: %w").String(), err)
 	}
 	if n == nil {
 		fmt.Println(aurora.Green("Success!").Bold())
+		return nil
 	}
-	for {
-		f, err := w.NewField(n)
-		if err != nil {
+	// Let the user edit the existing fields.
+	if err := w.ManageFields(n); err != nil {
+		if w.skip(err) {
+			return nil
+		}
+		return err
+	}
+	if n = w.LookupNode(n.Name); n == nil {
+		return nil
+	}
+	// Let the user add mixins, fields, edges and indexes.
+	for _, step := range []func(*load.Schema) (bool, error){
+		func(n *load.Schema) (bool, error) {
+			m, fs, err := w.NewMixin(n)
+			if err != nil || m == nil {
+				return m == nil, err
+			}
+			fmt.Printf(mixinAddedFormat, aurora.Cyan(m.Code), aurora.Cyan(n.Name), describeMixedIn(fs))
+			return false, nil
+		},
+		func(n *load.Schema) (bool, error) {
+			f, err := w.NewField(n)
+			if err != nil || f == nil {
+				return f == nil, err
+			}
+			fmt.Printf(fieldAddedFormat, aurora.Cyan(f.Name), aurora.Cyan(n.Name))
+			return false, nil
+		},
+		func(n *load.Schema) (bool, error) {
+			e, err := w.NewEdge(n)
+			if err != nil || e == nil {
+				return e == nil, err
+			}
+			fmt.Printf(edgeAddedFormat, aurora.Cyan(e.Name), aurora.Cyan(n.Name))
+			return false, nil
+		},
+		func(n *load.Schema) (bool, error) {
+			i, err := w.NewIndex(n)
+			if err != nil || i == nil {
+				return i == nil, err
+			}
+			fmt.Printf(indexAddedFormat, aurora.Cyan(strings.Join(append(i.Fields, i.Edges...), ", ")), aurora.Cyan(n.Name))
+			return false, nil
+		},
+	} {
+		if n, err = w.repeat(n, step); n == nil || err != nil {
 			return err
 		}
-		if f == nil {
-			break
+	}
+	if err := w.ManageAnnotations(n); err != nil {
+		if w.skip(err) {
+			return nil
 		}
-		fmt.Println("TODO: Add message here")
+		return err
 	}
+	if n = w.LookupNode(n.Name); n == nil {
+		return nil
+	}
+	if n, err = w.repeat(n, func(n *load.Schema) (bool, error) {
+		h, err := w.NewHook(n)
+		if err != nil || h == nil {
+			return h == nil, err
+		}
+		fmt.Printf(hookAddedFormat, aurora.Cyan(strings.Join(h.Ops, ", ")), aurora.Cyan(n.Name))
+		return false, nil
+	}); n == nil || err != nil {
+		return err
+	}
+	p, err := w.NewPolicy(n)
+	switch {
+	case w.skip(err):
+	case err != nil:
+		return err
+	case p != nil:
+		fmt.Printf(policyAddedFormat, aurora.Cyan(n.Name))
+	}
+	fmt.Println(aurora.Green("Success!").Bold())
 	return nil
 }
+
+// repeat runs the step of the wizard on the node until it reports that it is done. The node is reloaded after each
+// run, a step whose change was not written or that was interrupted by a shortcut is run again. Returns nil, nil if the
+// node is gone, e.g. because the user undid its creation.
+func (w *Wapiti) repeat(n *load.Schema, step func(*load.Schema) (bool, error)) (*load.Schema, error) {
+	for {
+		done, err := step(n)
+		switch {
+		case w.skip(err):
+		case err != nil:
+			return nil, err
+		case done:
+			return n, nil
+		}
+		if n = w.LookupNode(n.Name); n == nil {
+			return nil, nil
+		}
+	}
+}
+
+// skip reports whether the error returned by a step of the wizard only skips the step: the change was not written or
+// the user entered a shortcut.
+func (w *Wapiti) skip(err error) bool {
+	switch {
+	case errors.Is(err, ErrNotWritten):
+		fmt.Println(aurora.Yellow(notWrittenMsg))
+		return true
+	case errors.Is(err, errShortcut):
+		return true
+	}
+	return false
+}
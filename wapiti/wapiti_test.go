@@ -0,0 +1,39 @@
+package wapiti
+
+import (
+	"entgo.io/ent/entc/load"
+	"github.com/stretchr/testify/require"
+	"testing"
+)
+
+func TestRepeat(t *testing.T) {
+	pet := &load.Schema{Name: "Pet"}
+	w := &Wapiti{spec: &load.SchemaSpec{Schemas: []*load.Schema{pet}}}
+	// Changes that were not written repeat the step, the node is reloaded after each run.
+	var runs int
+	n, err := w.repeat(pet, func(n *load.Schema) (bool, error) {
+		runs++
+		switch runs {
+		case 1:
+			return false, ErrNotWritten
+		case 2:
+			w.spec.Schemas = []*load.Schema{{Name: "Pet"}}
+			return false, nil
+		}
+		return true, nil
+	})
+	require.NoError(t, err)
+	require.Equal(t, 3, runs)
+	require.Equal(t, w.spec.Schemas[0], n)
+	require.NotSame(t, pet, n)
+
+	// The wizard stops if the node is gone.
+	n, err = w.repeat(n, func(*load.Schema) (bool, error) {
+		w.spec.Schemas = nil
+		return false, nil
+	})
+	require.NoError(t, err)
+	require.Nil(t, n)
+	_, err = w.repeat(pet, func(*load.Schema) (bool, error) { return true, errSchemaName })
+	require.Equal(t, errSchemaName, err)
+}
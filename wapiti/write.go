@@ -0,0 +1,260 @@
+package wapiti
+
+import (
+	"errors"
+	"fmt"
+	"github.com/c-bata/go-prompt"
+	"github.com/logrusorgru/aurora/v3"
+	"github.com/pmezard/go-difflib/difflib"
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// ErrNotWritten is returned if a change was not written to disk, either because wapiti runs in dry-run mode or
+// because the user rejected it.
+var ErrNotWritten = errors.New("change was not written")
+
+var confirmations = []prompt.Suggest{
+	{Text: "accept", Description: "Write the change"},
+	{Text: "reject", Description: "Discard the change"},
+	{Text: "edit", Description: "Edit the change in $EDITOR before writing it"},
+}
+
+// write writes b to the file with the given name and records the change in the journal. In dry-run mode the change
+// is printed as unified diff instead. When running interactively the user is shown the diff and asked to accept,
+// reject or edit the change.
+func (w *Wapiti) write(name string, b []byte) error {
+	jf, err := w.writeFile(name, b)
+	if err != nil {
+		return err
+	}
+	return w.record(jf)
+}
+
+// stage runs fn keeping the files it changes in memory: the syntax trees are updated, but the files are only
+// written after fn returned, and recorded as one entry in the journal. Schemas created by fn are known to LookupNode,
+// so that the changes can build on each other. Calls Reload afterwards if a file was written. Nothing is written if fn
+// fails.
+func (w *Wapiti) stage(fn func() error) error {
+	if w.staged != nil {
+		return fn()
+	}
+	fset, pkg, schemas := w.fset, w.ast, w.spec.Schemas
+	if w.fset == nil {
+		w.fset = token.NewFileSet()
+	}
+	w.ast = &ast.Package{Files: make(map[string]*ast.File)}
+	if pkg != nil {
+		w.ast.Name = pkg.Name
+		for n, f := range pkg.Files {
+			w.ast.Files[n] = f
+		}
+	}
+	w.staged = make(map[string][]byte)
+	err := fn()
+	files := w.staged
+	// The staged syntax trees are replaced by Reload once the files are written.
+	w.fset, w.ast, w.spec.Schemas, w.staged = fset, pkg, schemas, nil
+	if err != nil {
+		return err
+	}
+	return w.writeFiles(files)
+}
+
+// writeFiles writes the given files, keyed by name, and records them as one entry in the journal. Calls Reload
+// afterwards if a file was written. Files written before an error are recorded as well, so that they can be undone.
+func (w *Wapiti) writeFiles(files map[string][]byte) error {
+	names := make([]string, 0, len(files))
+	for n := range files {
+		names = append(names, n)
+	}
+	sort.Strings(names)
+	var (
+		changes []*journalFile
+		skipped bool
+		err     error
+	)
+	for _, n := range names {
+		jf, werr := w.writeFile(n, files[n])
+		if err = collect(&changes, &skipped, jf, werr); err != nil {
+			break
+		}
+	}
+	if len(changes) == 0 {
+		if err == nil && skipped {
+			err = ErrNotWritten
+		}
+		return err
+	}
+	if rerr := w.record(changes...); err == nil {
+		err = rerr
+	}
+	if rerr := w.Reload(); err == nil {
+		err = rerr
+	}
+	if err == nil && skipped {
+		err = ErrNotWritten
+	}
+	return err
+}
+
+// stageFile stages the contents b of the file with the given name and updates the syntax tree of the package.
+func (w *Wapiti) stageFile(name string, b []byte) error {
+	f, err := parser.ParseFile(w.fset, name, b, parser.ParseComments)
+	if err != nil {
+		return fmt.Errorf("parsing %s: %w", name, err)
+	}
+	w.staged[name] = b
+	w.ast.Files[name] = f
+	return nil
+}
+
+// writeFile works like write but does not record the change. The returned journalFile describes the change.
+func (w *Wapiti) writeFile(name string, b []byte) (*journalFile, error) {
+	old, err := ioutil.ReadFile(name)
+	exists := err == nil
+	if err != nil && !os.IsNotExist(err) {
+		return nil, fmt.Errorf("reading file %s: %w", name, err)
+	}
+	if w.cfg.DryRun {
+		fmt.Print(colorDiff(diff(name, old, b)))
+		return nil, ErrNotWritten
+	}
+	if w.interactive {
+		if b, err = confirm(name, old, b); err != nil {
+			return nil, err
+		}
+	}
+	if err := ioutil.WriteFile(name, b, 0644); err != nil {
+		return nil, fmt.Errorf("writing file %s: %w", name, err)
+	}
+	return &journalFile{Path: name, Before: contents(old, exists), After: contents(b, true)}, nil
+}
+
+// removeFile removes the file with the given name. The returned journalFile describes the change. In dry-run mode the
+// removal is printed as unified diff instead.
+func (w *Wapiti) removeFile(name string) (*journalFile, error) {
+	old, err := ioutil.ReadFile(name)
+	if err != nil {
+		return nil, fmt.Errorf("reading file %s: %w", name, err)
+	}
+	if w.cfg.DryRun {
+		fmt.Print(colorDiff(diff(name, old, nil)))
+		return nil, ErrNotWritten
+	}
+	if err := os.Remove(name); err != nil {
+		return nil, fmt.Errorf("removing file %s: %w", name, err)
+	}
+	return &journalFile{Path: name, Before: contents(old, true)}, nil
+}
+
+// confirm shows the user the change to the file with the given name and asks to accept, reject or edit it. Returns
+// the accepted contents of the file.
+func confirm(name string, old, b []byte) ([]byte, error) {
+	for {
+		fmt.Print(colorDiff(diff(name, old, b)))
+		switch a := ask(confirmations, "Write this change to %s (accept/reject/edit) [%s]:", name, aurora.Yellow("accept")); a {
+		case "", "accept":
+			return b, nil
+		case "reject":
+			return nil, ErrNotWritten
+		case "edit":
+			var err error
+			if b, err = openEditor(name, b); err != nil {
+				return nil, err
+			}
+		default:
+			fmt.Println(aurora.Red(fmt.Sprintf("unknown answer %q", a)))
+		}
+	}
+}
+
+// diff returns the unified diff between the contents old and new of the file with the given name.
+func diff(name string, old, new []byte) string {
+	p := filepath.ToSlash(name)
+	if !strings.HasPrefix(p, "/") {
+		p = "/" + p
+	}
+	from, to := "a"+p, "b"+p
+	if old == nil {
+		from = "/dev/null"
+	}
+	if new == nil {
+		to = "/dev/null"
+	}
+	d, _ := difflib.GetUnifiedDiffString(difflib.UnifiedDiff{
+		A:        splitLines(old),
+		B:        splitLines(new),
+		FromFile: from,
+		ToFile:   to,
+		Context:  3,
+	})
+	return d
+}
+
+// splitLines splits b into lines, keeping the line endings.
+func splitLines(b []byte) []string {
+	ls := strings.SplitAfter(string(b), "\n")
+	if ls[len(ls)-1] == "" {
+		ls = ls[:len(ls)-1]
+	}
+	return ls
+}
+
+// colorDiff highlights added and removed lines of the unified diff d.
+func colorDiff(d string) string {
+	b := new(strings.Builder)
+	for _, l := range strings.SplitAfter(d, "\n") {
+		switch {
+		case strings.HasPrefix(l, "+++"), strings.HasPrefix(l, "---"):
+			b.WriteString(aurora.Bold(l).String())
+		case strings.HasPrefix(l, "+"):
+			b.WriteString(aurora.Green(l).String())
+		case strings.HasPrefix(l, "-"):
+			b.WriteString(aurora.Red(l).String())
+		case strings.HasPrefix(l, "@@"):
+			b.WriteString(aurora.Cyan(l).String())
+		default:
+			b.WriteString(l)
+		}
+	}
+	return b.String()
+}
+
+// openEditor opens b in the users $EDITOR and returns the edited contents.
+func openEditor(name string, b []byte) ([]byte, error) {
+	f, err := ioutil.TempFile("", "wapiti-*-"+filepath.Base(name))
+	if err != nil {
+		return nil, fmt.Errorf("creating temporary file: %w", err)
+	}
+	defer os.Remove(f.Name())
+	if _, err := f.Write(b); err != nil {
+		f.Close()
+		return nil, fmt.Errorf("writing temporary file: %w", err)
+	}
+	if err := f.Close(); err != nil {
+		return nil, fmt.Errorf("writing temporary file: %w", err)
+	}
+	editor := os.Getenv("EDITOR")
+	if editor == "" {
+		editor = "vi"
+	}
+	args := append(strings.Fields(editor), f.Name())
+	cmd := exec.Command(args[0], args[1:]...)
+	cmd.Stdin, cmd.Stdout, cmd.Stderr = os.Stdin, os.Stdout, os.Stderr
+	if err := cmd.Run(); err != nil {
+		return nil, fmt.Errorf("running editor %s: %w", editor, err)
+	}
+	b, err = ioutil.ReadFile(f.Name())
+	if err != nil {
+		return nil, fmt.Errorf("reading temporary file: %w", err)
+	}
+	return b, nil
+}
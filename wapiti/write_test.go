@@ -0,0 +1,36 @@
+package wapiti
+
+import (
+	"github.com/masseelch/wapiti/wapiti/config"
+	"github.com/stretchr/testify/require"
+	"io/ioutil"
+	"path/filepath"
+	"testing"
+)
+
+func TestDiff(t *testing.T) {
+	require.Equal(t, `--- a/pet.go
++++ b/pet.go
+@@ -1,2 +1,3 @@
+ package schema
++
+ type Pet struct{}
+`, diff("pet.go", []byte("package schema\ntype Pet struct{}\n"), []byte("package schema\n\ntype Pet struct{}\n")))
+	require.Contains(t, diff("pet.go", nil, []byte("package schema\n")), "--- /dev/null\n")
+}
+
+func TestWriteDryRun(t *testing.T) {
+	dir := t.TempDir()
+	p := filepath.Join(dir, "pet.go")
+	require.NoError(t, ioutil.WriteFile(p, []byte("package schema\n"), 0644))
+	w := &Wapiti{cfg: &config.Config{SchemaPath: dir, DryRun: true}}
+	require.ErrorIs(t, w.write(p, []byte("package schema\n\ntype Pet struct{}\n")), ErrNotWritten)
+	b, err := ioutil.ReadFile(p)
+	require.NoError(t, err)
+	require.Equal(t, "package schema\n", string(b))
+	w.cfg.DryRun = false
+	require.NoError(t, w.write(p, []byte("package schema\n\ntype Pet struct{}\n")))
+	b, err = ioutil.ReadFile(p)
+	require.NoError(t, err)
+	require.Equal(t, "package schema\n\ntype Pet struct{}\n", string(b))
+}